@@ -0,0 +1,145 @@
+package cmd
+
+// Providers which CI pipelines can be generated for
+var ciProviders = []string{"github", "gitlab"}
+
+// GitHub Actions expressions share their delimiters with Go templates,
+// so the workflow is written as-is rather than being rendered
+var CI_GITHUB_BASE = `name: "CI"
+
+on:
+  push:
+    branches: [ main ]
+  pull_request:
+    branches: [ main ]
+
+jobs:
+  test:
+    runs-on: ubuntu-latest
+
+    steps:
+    - name: Checkout code
+      uses: actions/checkout@v4
+
+    - name: Set up Go
+      uses: actions/setup-go@v5
+      with:
+        go-version-file: go.mod
+
+    - name: Build
+      run: go build -v ./...
+
+    - name: Vet
+      run: go vet ./...
+
+    - name: Test with race detector
+      run: go test -race -coverprofile=coverage.out -covermode=atomic ./...
+
+    - name: Upload coverage
+      uses: actions/upload-artifact@v4
+      with:
+        name: coverage
+        path: coverage.out
+
+  docker:
+    needs: test
+    runs-on: ubuntu-latest
+
+    steps:
+    - name: Checkout code
+      uses: actions/checkout@v4
+
+    - name: Set up Docker Buildx
+      uses: docker/setup-buildx-action@v3
+
+    - name: Build image
+      uses: docker/build-push-action@v6
+      with:
+        context: .
+        push: false
+        tags: ${{ github.event.repository.name }}:${{ github.sha }}
+        # Tests have already run in the test job
+        build-args: |
+          VERSION=${{ github.ref_name }}
+          COMMIT=${{ github.sha }}
+          RUN_TESTS=false
+        cache-from: type=gha
+        cache-to: type=gha,mode=max
+`
+
+var CI_GITLAB_BASE = `stages:
+  - build
+  - test
+  - docker
+
+variables:
+  # Keep the module cache inside the project so it can be cached
+  GOPATH: $CI_PROJECT_DIR/.go
+
+default:
+  image: golang:{{.GoVersion}}
+  cache:
+    key: go-modules
+    paths:
+      - .go/pkg/mod/
+
+build:
+  stage: build
+  script:
+    - go build -v ./...
+    - go vet ./...
+
+test:
+  stage: test
+  script:
+    - go test -race -coverprofile=coverage.out -covermode=atomic ./...
+    - go tool cover -func=coverage.out
+  coverage: '/total:\s+\(statements\)\s+(\d+\.\d+)%/'
+  artifacts:
+    paths:
+      - coverage.out
+
+docker:
+  stage: docker
+  image: docker:24
+  services:
+    - docker:24-dind
+  variables:
+    DOCKER_BUILDKIT: "1"
+  cache: []
+  script:
+    # Tests have already run in the test stage
+    - docker build
+      --build-arg VERSION=$CI_COMMIT_REF_NAME
+      --build-arg COMMIT=$CI_COMMIT_SHA
+      --build-arg RUN_TESTS=false
+      -t {{.AppName}}:$CI_COMMIT_SHORT_SHA .
+`
+
+// Creates CI pipelines for the configured providers
+func GenerateCIFiles(target string, data TemplateData) error {
+	for _, provider := range data.CI {
+		switch provider {
+		case "github":
+			if err := createDirectories(target, ".github/workflows"); err != nil {
+				return err
+			}
+			workflow, err := createFile(".github/workflows/ci.yaml", target)
+			if err != nil {
+				return err
+			}
+			if err := writeFile(workflow, CI_GITHUB_BASE); err != nil {
+				return err
+			}
+		case "gitlab":
+			pipeline, err := createFile(".gitlab-ci.yml", target)
+			if err != nil {
+				return err
+			}
+			if err := writeTemplate(pipeline, CI_GITLAB_BASE, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -1,142 +1,1025 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go/token"
+	"math"
+	"net/mail"
 	"os"
-	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/spf13/cobra"
-	"k8s.io/apimachinery/pkg/util/yaml"
+	"github.com/spf13/pflag"
+	"golang.org/x/mod/semver"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
-// Config interface must contain app information
-// collected from command flags or YAML file
-type Config interface {
-	getAppName() string   // Returns name of application
-	getDirectory() string // Returns target directory
-	getModName() string   // Returns name of go module
+// EndpointDefinition contains information about
+// custom endpoints defined in YAML configuration files
+type EndpointDefinition struct {
+	Path        string `yaml:"path" json:"path"`     // Path with {name} parameters, (i.e. /v1/users/{id})
+	Method      string `yaml:"method" json:"method"` // HTTP method, defaults to GET
+	Description string `yaml:"description" json:"description"`
+	handler     string // Name of the handler of built-in endpoints
 }
 
-// FlagConfig contains app information collected
-// from command line flags
-type FlagConfig struct {
-	AppName   string
-	Directory string
-	ModName   string
-}
+// Matches static path segments and {name} path parameters
+var (
+	pathSegmentRegex = regexp.MustCompile(`^[A-Za-z0-9._~-]+$`)
+	pathParamRegex   = regexp.MustCompile(`^\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+)
 
-// Returns name of application
-func (c FlagConfig) getAppName() string {
-	return c.AppName
+// Returns the name of the handler method serving the endpoint, marking
+// parameters so that they don't share names with static segments
+// (i.e. GET /v1/users/{id} -> v1UsersByIdGetHandler)
+func (e EndpointDefinition) HandlerName() string {
+	if e.handler != "" {
+		return e.handler
+	}
+	segments := strings.Split(e.Path, "/")
+	for i, segment := range segments {
+		if m := pathParamRegex.FindStringSubmatch(segment); m != nil {
+			segments[i] = "by_" + m[1]
+		}
+	}
+	method := strings.ToLower(e.Method)
+	name := capitalizeAfterSlash(strings.Join(segments, "/")) + strings.ToUpper(method[:1]) + method[1:] + "Handler"
+	// Identifiers can't start with a digit, (i.e. GET /2fa/codes -> path2faCodesGetHandler)
+	if !token.IsIdentifier(name) {
+		name = "path" + name
+	}
+	return name
 }
 
-// Returns target directory
-func (c FlagConfig) getDirectory() string {
-	return c.Directory
+// Returns the names of the path parameters of the endpoint
+func (e EndpointDefinition) params() []string {
+	var params []string
+	for _, segment := range strings.Split(e.Path, "/") {
+		if m := pathParamRegex.FindStringSubmatch(segment); m != nil {
+			params = append(params, m[1])
+		}
+	}
+	return params
 }
 
-// Returns name of go module
-func (c FlagConfig) getModName() string {
-	return c.ModName
+// Returns the path with parameters written as :name rather than {name}
+func (e EndpointDefinition) colonPath() string {
+	segments := strings.Split(e.Path, "/")
+	for i, segment := range segments {
+		if m := pathParamRegex.FindStringSubmatch(segment); m != nil {
+			segments[i] = ":" + m[1]
+		}
+	}
+	return strings.Join(segments, "/")
 }
 
-// EndpointDefinition contains information about
-// custom endpoints defined in YAML configuration files
-type EndpointDefinition struct {
-	Path         string `yaml:"path"`
-	Method       string `yaml:"method"`
-	Description  string `yaml:"description"`
-	functionName string
+// Returns the path with parameters replaced by placeholder values
+func (e EndpointDefinition) examplePath() string {
+	segments := strings.Split(e.Path, "/")
+	for i, segment := range segments {
+		if m := pathParamRegex.FindStringSubmatch(segment); m != nil {
+			segments[i] = "test-" + m[1]
+		}
+	}
+	return strings.Join(segments, "/")
 }
 
 // Returns a templated string describing a handler function
-// for the given endpoint
-func (e *EndpointDefinition) GenerateHandlerFunction() string {
-	// Set the function name to a camelCase alphanumeric representation
-	// of the endpoint path
-
-	e.functionName = fmt.Sprintf("%s%sHandler", capitalizeAfterSlash(e.Path), e.Method)
-
+// for the given endpoint, replying with its path parameters
+func (e EndpointDefinition) GenerateHandlerFunction() string {
+	// Built-in endpoints have their own handlers
+	if e.handler != "" {
+		return ""
+	}
+	reply := `replyTextContent(w, r, http.StatusOK, "OK")`
+	if params := e.params(); len(params) > 0 {
+		values := make([]string, len(params))
+		for i, p := range params {
+			values[i] = fmt.Sprintf("%q: r.PathValue(%q)", p, p)
+		}
+		reply = fmt.Sprintf("replyJSONContent(w, r, http.StatusOK, envelope{%s})", strings.Join(values, ", "))
+	}
+	description := e.Description
+	if description == "" {
+		description = fmt.Sprintf("Handles %s %s", e.Method, e.Path)
+	}
 	templateFunction := `
-	// %s
-	func (a *application) %s(w http.ResponseWriter, r *http.Request) {
-		replyTextContent(w, r, http.StatusOK, "OK")
-	} 
-	`
-	return fmt.Sprintf(templateFunction, e.Description, e.functionName)
+// %s
+func (a *application) %s(w http.ResponseWriter, r *http.Request) {
+	%s
+}
+`
+	return fmt.Sprintf(templateFunction, description, e.HandlerName(), reply)
+}
+
+// Returns a templated line of test code requesting the endpoint
+// and checking that its handler replied
+func (e EndpointDefinition) GenerateTestRequest() string {
+	// Built-in endpoints have their own tests
+	if e.handler != "" {
+		return ""
+	}
+	expBody := "OK"
+	if params := e.params(); len(params) > 0 {
+		expBody = "test-" + params[len(params)-1]
+	}
+	// Replies to HEAD requests have no body
+	if e.Method == "HEAD" {
+		expBody = ""
+	}
+	return fmt.Sprintf("_ = requestHelper(t, %s, url+%q, %q, http.StatusOK)", httpMethods[e.Method], e.examplePath(), expBody)
 }
 
 // Returns a templated string to append to README.md API Endpoint table
-func (e *EndpointDefinition) GenerateReadmeTableEntry() string {
-	return fmt.Sprintf("| %s | %s | %s |\n", e.Path, e.Method, e.Description)
+func (e EndpointDefinition) GenerateReadmeTableEntry() string {
+	return fmt.Sprintf("|`%s`| %s | %s |", e.Path, e.Method, e.Description)
 }
 
-// Returns a templated line of code attaching handler function to path via router
-func (e *EndpointDefinition) GenerateRouterAttachment() string {
-	// ToDo: Format method into http.MethodXYZ form rather than just XYZ
-	return fmt.Sprintf("router.HandlerFunc(%s, %s, a.%s)", e.Method, e.Path, e.functionName)
+// Checks that the endpoint can be routed to by every router
+func (e EndpointDefinition) validate() error {
+	var p problems
+	if _, ok := httpMethods[e.Method]; !ok {
+		p.addf("method", "endpoint %s has unsupported method %q", e.Path, e.Method)
+	}
+	if !strings.HasPrefix(e.Path, "/") || strings.HasSuffix(e.Path, "/") {
+		p.addf("path", "endpoint path %q must start and must not end with /", e.Path)
+		return p.err()
+	}
+	seen := map[string]bool{}
+	for _, segment := range strings.Split(e.Path, "/")[1:] {
+		if m := pathParamRegex.FindStringSubmatch(segment); m != nil {
+			if seen[m[1]] {
+				p.addf("path", "endpoint path %q has parameter %s more than once", e.Path, m[1])
+				break
+			}
+			seen[m[1]] = true
+			continue
+		}
+		if !pathSegmentRegex.MatchString(segment) {
+			p.addf("path", "endpoint path %q has invalid segment %q, must be alphanumeric or a {name} parameter", e.Path, segment)
+			break
+		}
+	}
+	return p.err()
 }
 
-// YamlConfig contains app information collected
-// from YAML configuration file
-type YamlConfig struct {
-	AppName   string               `yaml:"appName"`
-	Directory string               `yaml:"directory"`
-	ModName   string               `yaml:"modName"`
-	Endpoints []EndpointDefinition `yaml:"endpoints"`
+// Checks that no two endpoints match the same requests or are served
+// by handlers of the same name, and that no two endpoints are rejected
+// or shadowed by the router: httprouter can't choose between a
+// parameter and another segment in the same position, gin can't name
+// parameters in the same position differently, http.ServeMux can't
+// choose between endpoints when neither is more specific than the
+// other, and gorilla routes requests to the first endpoint matching
+// them. Problems are reported against the index of the later endpoint
+func validateEndpoints(endpoints []EndpointDefinition, router string) error {
+	var p problems
+	var routes []EndpointDefinition
+	for i, e := range append(append([]EndpointDefinition{}, builtinEndpoints...), endpoints...) {
+		field := fmt.Sprintf("[%d]", i-len(builtinEndpoints))
+		for _, prev := range routes {
+			if prev.HandlerName() == e.HandlerName() {
+				p.addf(field, "endpoint %s %s has the same handler name %s as %s %s, use another path", e.Method, e.Path, e.HandlerName(), prev.Method, prev.Path)
+				break
+			}
+			if router == "stdlib" && muxConflict(e, prev) {
+				p.addf(field, "endpoints %s %s and %s %s conflict with the stdlib router as neither is more specific than the other, use another router or path", e.Method, e.Path, prev.Method, prev.Path)
+				break
+			}
+			if prev.Method != e.Method {
+				continue
+			}
+			if routeKey(prev.Path) == routeKey(e.Path) {
+				p.addf(field, "endpoint %s %s conflicts with %s %s", e.Method, e.Path, prev.Method, prev.Path)
+				break
+			}
+			if router == "httprouter" && ambiguousPaths(e.Path, prev.Path) {
+				p.addf(field, "endpoints %s and %s are ambiguous with the httprouter router, use another router or path", e.Path, prev.Path)
+				break
+			}
+			if router == "gin" && conflictingParams(e.Path, prev.Path) {
+				p.addf(field, "endpoints %s and %s name the same parameter differently, which the gin router rejects, use the same name", e.Path, prev.Path)
+				break
+			}
+			if router == "gorilla" && shadowedPath(e.Path, prev.Path) {
+				p.addf(field, "endpoint %s is never reached with the gorilla router as %s matches its requests first, list it before %s", e.Path, prev.Path, prev.Path)
+				break
+			}
+		}
+		routes = append(routes, e)
+	}
+	return p.err()
 }
 
-// Returns name of application
-func (c YamlConfig) getAppName() string {
-	return c.AppName
+// Returns the path with parameter names removed, as
+// they don't affect which requests are matched
+func routeKey(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if pathParamRegex.MatchString(segment) {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
 }
 
-// Returns target directory
-func (c YamlConfig) getDirectory() string {
-	return c.Directory
+// Returns whether paths first differ in a segment which is a parameter
+func ambiguousPaths(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		return pathParamRegex.MatchString(as[i]) || pathParamRegex.MatchString(bs[i])
+	}
+	return false
 }
 
-// Returns name of go module
-func (c YamlConfig) getModName() string {
-	return c.ModName
+// Returns whether paths have parameters of different names
+// in the same position, following the same static segments
+func conflictingParams(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		return pathParamRegex.MatchString(as[i]) && pathParamRegex.MatchString(bs[i])
+	}
+	return false
 }
 
-// Checks if the config should be loaded from a YAML
-// or from command flags and returns the appropriate
-// Config interface or an error if applicable
-func setConfiguration(cmd *cobra.Command) (Config, error) {
-	// Check if a YAML was provided
-	confFile, err := cmd.Flags().GetString("config")
+// Returns whether every request matching path also
+// matches the earlier path, which has parameters
+// in place of some of its static segments
+func shadowedPath(path, earlier string) bool {
+	ps, es := strings.Split(path, "/"), strings.Split(earlier, "/")
+	if len(ps) != len(es) {
+		return false
+	}
+	for i := range ps {
+		if ps[i] != es[i] && !pathParamRegex.MatchString(es[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns whether http.ServeMux rejects registering both endpoints, which
+// it does when they match some of the same requests but neither matches
+// only requests the other does. GET endpoints match HEAD requests too
+func muxConflict(a, b EndpointDefinition) bool {
+	var aMoreSpecific, bMoreSpecific bool
+	switch {
+	case a.Method == b.Method:
+	case a.Method == "HEAD" && b.Method == "GET":
+		aMoreSpecific = true
+	case a.Method == "GET" && b.Method == "HEAD":
+		bMoreSpecific = true
+	default:
+		return false
+	}
+	as, bs := strings.Split(a.Path, "/"), strings.Split(b.Path, "/")
+	if len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		aParam, bParam := pathParamRegex.MatchString(as[i]), pathParamRegex.MatchString(bs[i])
+		switch {
+		case aParam && bParam:
+		case aParam:
+			bMoreSpecific = true
+		case bParam:
+			aMoreSpecific = true
+		case as[i] != bs[i]:
+			// No request matches both
+			return false
+		}
+	}
+	return aMoreSpecific && bMoreSpecific
+}
+
+// Default time allowed for a single dependency check to complete
+const defaultHealthCheckTimeout = 2 * time.Second
+
+// HealthCheckDefinition contains information about dependency
+// checks run by the readiness endpoint of the generated server
+type HealthCheckDefinition struct {
+	Name    string `yaml:"name" json:"name"`
+	Type    string `yaml:"type" json:"type"`       // One of http or disk
+	Target  string `yaml:"target" json:"target"`   // URL for http checks, directory for disk checks
+	Timeout string `yaml:"timeout" json:"timeout"` // Duration string, (i.e. 500ms, 2s)
+}
+
+// Returns a templated line of code registering the dependency check
+// with the health registry of the generated application
+func (h HealthCheckDefinition) GenerateRegistration() string {
+	timeout := defaultHealthCheckTimeout
+	if d, err := time.ParseDuration(h.Timeout); err == nil {
+		timeout = d
+	}
+	checker := fmt.Sprintf("httpChecker(%q)", h.Target)
+	if h.Type == "disk" {
+		checker = fmt.Sprintf("diskChecker(%q)", h.Target)
+	}
+	return fmt.Sprintf("a.health.Register(%q, %s, %s)", h.Name, durationLiteral(timeout), checker)
+}
+
+// Checks that the dependency check can be templated into valid code
+func (h HealthCheckDefinition) validate() error {
+	var p problems
+	if h.Name == "" {
+		p.addf("name", "health check is missing a `name` field")
+	}
+	if h.Type != "http" && h.Type != "disk" {
+		p.addf("type", "health check %s has unsupported type %q, must be http or disk", h.Name, h.Type)
+	}
+	if h.Target == "" {
+		p.addf("target", "health check %s is missing a `target` field", h.Name)
+	}
+	if h.Timeout != "" {
+		if _, err := time.ParseDuration(h.Timeout); err != nil {
+			p.addf("timeout", "health check %s has invalid timeout: %w", h.Name, err)
+		}
+	}
+	return p.err()
+}
+
+// ScalarString is a string which can be unmarshalled from any
+// YAML scalar, so that values such as 4000 or true need no quotes
+type ScalarString string
+
+// Stores the text representation of a YAML scalar
+func (s *ScalarString) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*s = ""
+	case string:
+		*s = ScalarString(v)
+	case json.Number, bool:
+		*s = ScalarString(fmt.Sprint(v))
+	default:
+		return fmt.Errorf("expected a scalar value, got %s", string(b))
+	}
+	return nil
+}
+
+// StringList is a list of strings which can be unmarshalled
+// from either a single YAML scalar or a YAML sequence
+type StringList []string
+
+// Stores a YAML scalar as a single element list
+func (l *StringList) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*l = StringList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return fmt.Errorf("expected a string or list of strings, got %s", string(b))
+	}
+	*l = list
+	return nil
+}
+
+// Go types used to store each supported setting type
+var configFieldTypes = map[string]string{
+	"string":   "string",
+	"int":      "int",
+	"bool":     "bool",
+	"float":    "float64",
+	"duration": "time.Duration",
+}
+
+// Names used by settings and flags that every generated server defines
+var reservedConfigNames = []string{"port", "shutdownDelay", "config", "version", "printConfig", "healthcheck"}
+
+// Matches setting names which are valid unexported Go identifiers
+var configNameRegex = regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`)
+
+// ConfigFieldDefinition contains information about custom settings
+// loaded by the generated server from defaults, a config file,
+// environment variables and command line flags
+type ConfigFieldDefinition struct {
+	Name        string       `yaml:"name" json:"name"` // camelCase name of the setting
+	Type        string       `yaml:"type" json:"type"` // One of string, int, bool, float or duration
+	Default     ScalarString `yaml:"default" json:"default"`
+	Env         string       `yaml:"env" json:"env"` // Environment variable, (default $NAME in SCREAMING_SNAKE_CASE)
+	Secret      bool         `yaml:"secret" json:"secret"`
+	Required    bool         `yaml:"required" json:"required"`
+	Description string       `yaml:"description" json:"description"`
+}
+
+// Port the generated server listens on if none is configured
+const defaultPort = 4000
+
+// Returns the settings which every generated server defines
+func builtinConfigFields(port int) []ConfigFieldDefinition {
+	return []ConfigFieldDefinition{
+		{Name: "port", Type: "int", Default: ScalarString(strconv.Itoa(port)), Env: "PORT", Description: "API server port"},
+		{Name: "shutdownDelay", Type: "duration", Default: "5s", Env: "SHUTDOWN_DELAY", Description: "Time to keep serving requests after readiness starts failing"},
+	}
+}
+
+// Returns the name of the command line flag and config file key of the setting
+func (c ConfigFieldDefinition) flagName() string {
+	return kebabCase(c.Name)
+}
+
+// Returns the environment variable the setting is loaded from
+func (c ConfigFieldDefinition) envName() string {
+	if c.Env != "" {
+		return c.Env
+	}
+	return strings.ToUpper(strings.ReplaceAll(kebabCase(c.Name), "-", "_"))
+}
+
+// Returns the Go source representation of the default value of the
+// setting, which is parsed rather than written as is so that values
+// such as T or 08 are written as valid literals (i.e. true and 8)
+func (c ConfigFieldDefinition) defaultLiteral() string {
+	d := string(c.Default)
+	switch c.Type {
+	case "string":
+		return strconv.Quote(d)
+	case "bool":
+		b, _ := strconv.ParseBool(d)
+		return strconv.FormatBool(b)
+	case "int":
+		i, _ := strconv.ParseInt(d, 10, 64)
+		return strconv.FormatInt(i, 10)
+	case "float":
+		f, _ := strconv.ParseFloat(d, 64)
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	case "duration":
+		parsed, err := time.ParseDuration(d)
+		if err != nil || parsed == 0 {
+			return "0"
+		}
+		return durationLiteral(parsed)
+	}
+	return d
+}
+
+// Returns a templated line of code declaring the setting in the config struct
+func (c ConfigFieldDefinition) GenerateStructField() string {
+	if c.Description == "" {
+		return fmt.Sprintf("%s %s", c.Name, configFieldTypes[c.Type])
+	}
+	return fmt.Sprintf("%s %s // %s", c.Name, configFieldTypes[c.Type], c.Description)
+}
+
+// Returns a templated string to append to README.md Configuration table
+func (c ConfigFieldDefinition) GenerateReadmeTableEntry() string {
+	notes := c.Description
+	if c.Required {
+		notes = strings.TrimSpace("(Required) " + notes)
+	}
+	if c.Secret {
+		notes = strings.TrimSpace("(Secret) " + notes)
+	}
+	def := "-"
+	if c.Default != "" {
+		def = fmt.Sprintf("`%s`", c.Default)
+	}
+	return fmt.Sprintf("| `-%s` | `%s` | %s | %s | %s |", c.flagName(), c.envName(), c.Type, def, notes)
+}
+
+// Returns a templated line of code registering the setting as a command line flag
+func (c ConfigFieldDefinition) GenerateFlagBinding() string {
+	function := map[string]string{
+		"string":   "StringVar",
+		"int":      "IntVar",
+		"bool":     "BoolVar",
+		"float":    "Float64Var",
+		"duration": "DurationVar",
+	}[c.Type]
+	return fmt.Sprintf("fs.%s(&cfg.%s, %q, %s, %q)", function, c.Name, c.flagName(), c.defaultLiteral(), c.Description)
+}
+
+// Returns a templated entry describing where the setting can be loaded from
+func (c ConfigFieldDefinition) GenerateFieldEntry() string {
+	entry := fmt.Sprintf("{name: %q, env: %q", c.flagName(), c.envName())
+	if c.Secret {
+		entry += ", secret: true"
+	}
+	if c.Required {
+		entry += ", required: true"
+	}
+	return entry + "},"
+}
+
+// Returns the value of the setting passed to the container by docker-compose,
+// which can be overridden by the environment docker-compose is run from
+func (c ConfigFieldDefinition) composeValue() string {
+	if c.Default == "" {
+		return fmt.Sprintf("${%s}", c.envName())
+	}
+	return fmt.Sprintf("${%s:-%s}", c.envName(), c.Default)
+}
+
+// Returns a templated entry of the setting in an environment file
+func (c ConfigFieldDefinition) GenerateEnvFileEntry() string {
+	entry := fmt.Sprintf("%s=%s", c.envName(), c.Default)
+	if c.Description != "" {
+		entry = fmt.Sprintf("# %s\n%s", c.Description, entry)
+	}
+	return entry
+}
+
+// Returns a templated entry of the setting in a Kubernetes ConfigMap or Secret
+func (c ConfigFieldDefinition) GenerateManifestEntry() string {
+	return fmt.Sprintf("%s: %q", c.envName(), c.Default)
+}
+
+// Checks that the setting can be templated into valid code
+func (c ConfigFieldDefinition) validate() error {
+	var p problems
+	if !configNameRegex.MatchString(c.Name) {
+		p.addf("name", "config field %q must be a camelCase name starting with a lowercase letter", c.Name)
+	}
+	for _, r := range reservedConfigNames {
+		if c.Name == r {
+			p.addf("name", "config field %q is reserved by the generated server", c.Name)
+		}
+	}
+	if token.IsKeyword(c.Name) {
+		p.addf("name", "config field %q is a Go keyword, which can't name a variable of the generated server", c.Name)
+	}
+	if _, ok := configFieldTypes[c.Type]; !ok {
+		p.addf("type", "config field %s has unsupported type %q, must be string, int, bool, float or duration", c.Name, c.Type)
+		return p.err()
+	}
+	if c.Required && c.Default != "" {
+		p.addf("required", "config field %s cannot be required and have a default value", c.Name)
+	}
+	if c.Default == "" {
+		return p.err()
+	}
+	var err error
+	switch c.Type {
+	case "int":
+		_, err = strconv.Atoi(string(c.Default))
+	case "bool":
+		_, err = strconv.ParseBool(string(c.Default))
+	case "float":
+		var f float64
+		if f, err = strconv.ParseFloat(string(c.Default), 64); err == nil && (math.IsInf(f, 0) || math.IsNaN(f)) {
+			err = errors.New("must be a finite number")
+		}
+	case "duration":
+		_, err = time.ParseDuration(string(c.Default))
+	}
 	if err != nil {
-		return nil, err
+		p.addf("default", "config field %s has invalid default for type %s: %w", c.Name, c.Type, err)
 	}
-	if confFile != "" {
-		wd, err := os.Getwd()
-		if err != nil {
-			return nil, err
+	return p.err()
+}
+
+// ServiceDefinition contains information about services the
+// generated server depends on, which are run by docker-compose
+type ServiceDefinition struct {
+	Name        string            `yaml:"name" json:"name"`
+	Image       string            `yaml:"image" json:"image"`
+	Ports       []string          `yaml:"ports" json:"ports"` // host:container port mappings
+	Environment map[string]string `yaml:"environment" json:"environment"`
+}
+
+// Checks that the service can be templated into docker-compose.yaml
+func (s ServiceDefinition) validate() error {
+	var p problems
+	if s.Name == "" {
+		p.addf("name", "container service is missing a `name` field")
+	}
+	if s.Image == "" {
+		p.addf("image", "container service %s is missing an `image` field", s.Name)
+	}
+	return p.err()
+}
+
+// ContainerDefinition contains information about the
+// container environment of the generated server
+type ContainerDefinition struct {
+	Profile     string              `yaml:"profile" json:"profile"` // One of scratch, distroless or alpine
+	Environment map[string]string   `yaml:"environment" json:"environment"`
+	Services    []ServiceDefinition `yaml:"services" json:"services"`
+}
+
+// ResourceList contains Kubernetes compute resource quantities
+type ResourceList struct {
+	CPU    string `yaml:"cpu" json:"cpu"`       // (i.e. 100m, 1)
+	Memory string `yaml:"memory" json:"memory"` // (i.e. 64Mi, 1Gi)
+}
+
+// KubernetesDefinition contains information about how the generated
+// server is scheduled and scaled when deployed to Kubernetes
+type KubernetesDefinition struct {
+	Replicas             int               `yaml:"replicas" json:"replicas"`
+	MinReplicas          int               `yaml:"minReplicas" json:"minReplicas"`
+	MaxReplicas          int               `yaml:"maxReplicas" json:"maxReplicas"`
+	TargetCPUUtilization int               `yaml:"targetCPUUtilization" json:"targetCPUUtilization"` // Percentage of requested CPU
+	Requests             ResourceList      `yaml:"requests" json:"requests"`
+	Limits               ResourceList      `yaml:"limits" json:"limits"`
+	Ingress              IngressDefinition `yaml:"ingress" json:"ingress"`
+}
+
+// IngressDefinition contains information about how the generated server
+// is exposed outside of the cluster, which is disabled if no host is set
+type IngressDefinition struct {
+	Host      string `yaml:"host" json:"host"`
+	ClassName string `yaml:"className" json:"className"`
+	TLSSecret string `yaml:"tlsSecret" json:"tlsSecret"` // Secret containing the TLS certificate for host
+}
+
+// Returns the settings with any unset values replaced by defaults
+func (k KubernetesDefinition) withDefaults() KubernetesDefinition {
+	if k.Replicas == 0 {
+		k.Replicas = 2
+	}
+	if k.MinReplicas == 0 {
+		k.MinReplicas = k.Replicas
+	}
+	if k.MaxReplicas == 0 {
+		k.MaxReplicas = k.MinReplicas + 3
+	}
+	if k.TargetCPUUtilization == 0 {
+		k.TargetCPUUtilization = 80
+	}
+	if k.Requests.CPU == "" {
+		k.Requests.CPU = "100m"
+	}
+	if k.Requests.Memory == "" {
+		k.Requests.Memory = "64Mi"
+	}
+	if k.Limits.CPU == "" {
+		k.Limits.CPU = "500m"
+	}
+	if k.Limits.Memory == "" {
+		k.Limits.Memory = "128Mi"
+	}
+	return k
+}
+
+// Checks that the settings can be templated into valid manifests
+func (k KubernetesDefinition) validate() error {
+	var p problems
+	if k.Replicas < 1 {
+		p.addf("replicas", "kubernetes replicas must be at least 1")
+	}
+	if k.MinReplicas < 1 {
+		p.addf("minReplicas", "kubernetes minReplicas must be at least 1")
+	} else if k.MinReplicas > k.MaxReplicas {
+		p.addf("maxReplicas", "kubernetes minReplicas %d cannot exceed maxReplicas %d", k.MinReplicas, k.MaxReplicas)
+	}
+	if k.TargetCPUUtilization < 1 || k.TargetCPUUtilization > 100 {
+		p.addf("targetCPUUtilization", "kubernetes targetCPUUtilization must be between 1 and 100")
+	}
+	for _, q := range [][]string{
+		{"requests.cpu", k.Requests.CPU},
+		{"requests.memory", k.Requests.Memory},
+		{"limits.cpu", k.Limits.CPU},
+		{"limits.memory", k.Limits.Memory},
+	} {
+		if _, err := resource.ParseQuantity(q[1]); err != nil {
+			p.addf(q[0], "invalid kubernetes resource quantity %q: %w", q[1], err)
 		}
-		return loadYamlConfig(filepath.Join(wd, confFile))
 	}
-	// Check if flags were provided
-	return loadFlagConfig(cmd)
+	return p.err()
+}
+
+// SystemdDefinition contains information about how the generated
+// server is run and exposed when deployed to a systemd host
+type SystemdDefinition struct {
+	User string `yaml:"user" json:"user"` // Service user, (default $appName)
+	Host string `yaml:"host" json:"host"` // Domain served by the reverse proxy, (default any)
+}
+
+// Returns the settings with any unset values replaced by defaults
+func (s SystemdDefinition) withDefaults(appName string) SystemdDefinition {
+	if s.User == "" {
+		s.User = appName
+	}
+	return s
+}
+
+// GitDefinition contains information about the git repository
+// initialized in the generated project
+type GitDefinition struct {
+	Init   bool   `yaml:"init" json:"init"`
+	Author string `yaml:"author" json:"author"` // Author of the initial commit, (i.e. Jane Doe <jane@example.com>)
+}
+
+// Checks that the initial commit can be authored by the given author
+func (g GitDefinition) validate() error {
+	if g.Author == "" {
+		return nil
+	}
+	addr, err := mail.ParseAddress(g.Author)
+	if err != nil {
+		return fieldError{"author", fmt.Errorf("git author %q must be formatted as Name <email>: %w", g.Author, err)}
+	}
+	// git refuses to commit without the name of the author
+	if addr.Name == "" {
+		return fieldError{"author", fmt.Errorf("git author %q has no name, it must be formatted as Name <email>", g.Author)}
+	}
+	return nil
+}
+
+// ServerDefinition contains information about a server generated
+// alongside other servers in a monorepo, whose unset settings are
+// taken from the top-level configuration
+type ServerDefinition struct {
+	Name         string                  `yaml:"name" json:"name"`       // Name of the server and its directory
+	ModName      string                  `yaml:"modName" json:"modName"` // Defaults to <modName>/<name>
+	Router       string                  `yaml:"router" json:"router"`
+	Port         int                     `yaml:"port" json:"port"` // Defaults to the top-level port plus the index of the server
+	Endpoints    []EndpointDefinition    `yaml:"endpoints" json:"endpoints"`
+	HealthChecks []HealthCheckDefinition `yaml:"healthChecks" json:"healthChecks"`
+	ConfigFields []ConfigFieldDefinition `yaml:"config" json:"config"`
+	Container    ContainerDefinition     `yaml:"container" json:"container"`
+}
+
+// PlatformDefinition contains information about the module of logging,
+// error response, health check and middleware code which generated
+// servers import rather than each defining their own copy
+type PlatformDefinition struct {
+	Generate bool   `yaml:"generate" json:"generate"` // Generate the module into internal/platform
+	Module   string `yaml:"module" json:"module"`     // Path of an existing module, or of the generated module
+	Version  string `yaml:"version" json:"version"`   // Version of an existing module, defaults to latest
+}
+
+// Returns whether generated servers import a platform module
+func (p PlatformDefinition) enabled() bool {
+	return p.Generate || p.Module != ""
+}
+
+// Returns the platform settings with the path of a generated module
+// defaulted to <modName>/platform, and the version of
+// an existing module defaulted to latest
+func (p PlatformDefinition) withDefaults(modName string) PlatformDefinition {
+	if p.Generate && p.Module == "" {
+		p.Module = modName + "/platform"
+	}
+	if !p.Generate && p.Module != "" && p.Version == "" {
+		p.Version = "latest"
+	}
+	return p
+}
+
+// Checks that the module path and version are valid and that
+// an existing module can be collected
+func (p PlatformDefinition) validate(offline bool) error {
+	var errs problems
+	if p.Module == "" {
+		return nil
+	}
+	if err := validateModulePath(p.Module); err != nil {
+		errs.addf("module", "invalid platform module path %q: %w", p.Module, err)
+	}
+	if p.Generate {
+		return errs.err()
+	}
+	if p.Version != "latest" && !semver.IsValid(p.Version) {
+		errs.addf("version", "invalid platform module version %q, must be latest or a semantic version (i.e. v1.2.0)", p.Version)
+	}
+	if offline {
+		errs.addf("module", "platform module %s can't be collected offline, set `generate` to generate it instead", p.Module)
+	}
+	return errs.err()
+}
 
+// YamlConfig contains app information collected from a
+// configuration file, environment variables and command flags
+type YamlConfig struct {
+	Extends      StringList              `yaml:"extends,omitempty" json:"extends,omitempty"` // Files or presets this configuration starts from
+	AppName      string                  `yaml:"appName" json:"appName"`
+	Directory    string                  `yaml:"directory" json:"directory"`
+	ModName      string                  `yaml:"modName" json:"modName"`
+	Router       string                  `yaml:"router" json:"router"`
+	Endpoints    []EndpointDefinition    `yaml:"endpoints" json:"endpoints"`
+	HealthChecks []HealthCheckDefinition `yaml:"healthChecks" json:"healthChecks"`
+	ConfigFields []ConfigFieldDefinition `yaml:"config" json:"config"`
+	Port         int                     `yaml:"port" json:"port"`
+	Container    ContainerDefinition     `yaml:"container" json:"container"`
+	Deploy       string                  `yaml:"deploy" json:"deploy"`
+	Kubernetes   KubernetesDefinition    `yaml:"kubernetes" json:"kubernetes"`
+	Systemd      SystemdDefinition       `yaml:"systemd" json:"systemd"`
+	CI           StringList              `yaml:"ci" json:"ci"`
+	Git          GitDefinition           `yaml:"git" json:"git"`
+	Modules      ModuleDefinition        `yaml:"modules" json:"modules"`
+	Services     []ServerDefinition      `yaml:"services,omitempty" json:"services,omitempty"` // Servers generated into a monorepo named appName
+	Platform     PlatformDefinition      `yaml:"platform" json:"platform"`
+}
+
+// Settings which can be set through command flags and TALBOT_*
+// environment variables, (i.e. --app-name and TALBOT_APP_NAME)
+var flagSettings = []struct {
+	flag string
+	path string // Path of the setting in configuration files
+}{
+	{"app-name", "appName"},
+	{"mod-name", "modName"},
+	{"dir", "directory"},
+	{"router", "router"},
+	{"port", "port"},
+	{"container-profile", "container.profile"},
+	{"deploy", "deploy"},
+	{"ci", "ci"},
+	{"git", "git.init"},
+	{"git-author", "git.author"},
+	{"go", "modules.go"},
+	{"toolchain", "modules.toolchain"},
+	{"offline", "modules.offline"},
+	{"vendor", "modules.vendor"},
+	{"tidy", "modules.tidy"},
+	{"platform", "platform.generate"},
+	{"platform-module", "platform.module"},
+}
+
+// Returns the environment variable a flag can be set through
+func flagEnvName(flag string) string {
+	return "TALBOT_" + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// Loads the configuration from the file given by --config, if any,
+// TALBOT_* environment variables and command flags, with flags taking
+// precedence over the environment and the environment over the file
+func setConfiguration(cmd *cobra.Command) (*YamlConfig, error) {
+	confFile, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return nil, err
+	}
+	return loadConfig(confFile, cmd.Flags())
 }
 
-// Reads configuration information from YAML file and returns
-// a YamlConfig object containing application information
-func loadYamlConfig(filename string) (*YamlConfig, error) {
-	yamlFile, err := os.ReadFile(filename)
+// Reads the configuration from its sources and returns a YamlConfig
+// object containing application information, or ValidationErrors
+// listing every problem found in the sources
+func loadConfig(filename string, flags *pflag.FlagSet) (*YamlConfig, error) {
+	yamlConf, v, err := readConfig(filename, flags)
 	if err != nil {
 		return nil, err
 	}
+	if err := v.result(configFileName(filename)); err != nil {
+		return nil, err
+	}
+	for _, w := range v.warnings {
+		fmt.Printf("Warning: %s\n", w.Error())
+	}
+	return yamlConf, nil
+}
+
+// Reads the configuration file, if given, overrides its settings with those
+// set through the environment and flags which were changed, and validates the
+// result. The file is read from stdin if filename is -, in the format given
+// by the config-format flag or detected from its extension. Returns the
+// configuration with defaults set and the validator holding any problems
+// and warnings found. An error is only returned if the file can't be read
+// or parsed
+func readConfig(filename string, flags *pflag.FlagSet) (*YamlConfig, *validator, error) {
 	yamlConf := &YamlConfig{}
-	err = yaml.Unmarshal(yamlFile, yamlConf)
+	v := newValidator(nil, nil)
+	if filename != "" {
+		format := ""
+		if flags != nil {
+			if f := flags.Lookup("config-format"); f != nil {
+				format = f.Value.String()
+			}
+		}
+		var err error
+		if v, err = readConfigFile(filename, format, yamlConf); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, s := range flagSettings {
+		if value, ok := os.LookupEnv(flagEnvName(s.flag)); ok && value != "" {
+			v.override(yamlConf, s.path, value, flagEnvName(s.flag))
+		}
+	}
+	if flags != nil {
+		for _, s := range flagSettings {
+			f := flags.Lookup(s.flag)
+			if f == nil || !f.Changed {
+				continue
+			}
+			value := f.Value.String()
+			if list, ok := f.Value.(pflag.SliceValue); ok {
+				value = strings.Join(list.GetSlice(), ",")
+			}
+			v.override(yamlConf, s.path, value, "--"+s.flag)
+		}
+	}
+	v.validateYamlConfig(yamlConf)
+	v.reportDeferred()
+	v.attribute(configFileName(filename))
+	return yamlConf, v, nil
+}
+
+// Sets the setting at path from its text representation, given by source,
+// recording a problem if it isn't valid for the setting. Problems found
+// with the value it replaces are discarded, as the value set is validated
+// instead
+func (v *validator) override(yamlConf *YamlConfig, path, value, source string) {
+	v.overrides[path] = source
+	v.discard(path)
+	field := reflect.ValueOf(yamlConf).Elem()
+	for _, name := range strings.Split(path, ".") {
+		field = fieldByJSONName(field, name)
+	}
+	var err error
+	switch {
+	case field.Type() == reflect.TypeOf(StringList{}):
+		list := StringList{}
+		for _, item := range strings.Split(value, ",") {
+			list = append(list, strings.TrimSpace(item))
+		}
+		field.Set(reflect.ValueOf(list))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Int:
+		var i int
+		if i, err = strconv.Atoi(value); err == nil {
+			field.SetInt(int64(i))
+		}
+	case field.Kind() == reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(value); err == nil {
+			field.SetBool(b)
+		}
+	}
+	if err != nil {
+		v.report(path, fmt.Sprintf("invalid value %q: %v", value, errors.Unwrap(err)))
+	}
+}
+
+// Returns the field of the struct with the given json name
+func fieldByJSONName(v reflect.Value, name string) reflect.Value {
+	for i := 0; i < v.NumField(); i++ {
+		if strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0] == name {
+			return v.Field(i)
+		}
+	}
+	panic(fmt.Sprintf("no field %s in %s", name, v.Type()))
+}
+
+// Returns the name of the configuration file used in messages
+func configFileName(filename string) string {
+	if filename == "-" {
+		return "<stdin>"
+	}
+	return filename
+}
+
+// Reads the configuration file, merged over the configurations it extends,
+// into yamlConf, returning a validator locating problems in the files read.
+// An error is only returned if a file can't be read or parsed
+func readConfigFile(filename, format string, yamlConf *YamlConfig) (*validator, error) {
+	doc, files, err := readConfigDocument(filename, format)
 	if err != nil {
 		return nil, err
 	}
+	v := newValidator(doc, files)
+	// Check the document against the schema before decoding it, so that
+	// unknown fields and values of the wrong type are located
+	v.checkNode("", doc, configSchema())
+	var raw any
+	if err := doc.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", configFileName(filename), err)
+	}
+	// Decode through JSON so that fields are matched by their json tags,
+	// as with configurations given in other formats
+	if raw != nil {
+		js, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", configFileName(filename), err)
+		}
+		if err := json.Unmarshal(js, yamlConf); err != nil {
+			return nil, fmt.Errorf("%s: %w", configFileName(filename), err)
+		}
+	}
+	return v, nil
+}
+
+// Sets defaults for any unset values of the configuration
+// and records every problem found with its values
+func (v *validator) validateYamlConfig(yamlConf *YamlConfig) {
 	if yamlConf.AppName == "" {
-		return nil, fmt.Errorf("no `appName` field set in YAML file")
+		v.report("appName", "no app name set, use the `appName` key, --app-name flag or "+flagEnvName("app-name"))
+	} else {
+		v.check("appName", validateAppName(yamlConf.AppName))
 	}
 	if yamlConf.Directory == "" {
 		yamlConf.Directory = "./"
@@ -144,46 +1027,274 @@ func loadYamlConfig(filename string) (*YamlConfig, error) {
 	if yamlConf.ModName == "" {
 		yamlConf.ModName = yamlConf.AppName
 	}
-	return yamlConf, nil
+	if yamlConf.ModName != "" {
+		v.check("modName", validateModulePath(yamlConf.ModName))
+	}
+	if yamlConf.Router == "" {
+		yamlConf.Router = "httprouter"
+	}
+	v.check("router", validateRouter(yamlConf.Router))
+	yamlConf.Modules = yamlConf.Modules.withDefaults()
+	v.check("modules", yamlConf.Modules.validate())
+	v.check("modules", yamlConf.Modules.validatePinned(yamlConf.Router))
+	v.validateEndpointList("endpoints", yamlConf.Endpoints, yamlConf.Router)
+	v.validateHealthCheckList("healthChecks", yamlConf.HealthChecks)
+	v.validateConfigFieldList("config", yamlConf.ConfigFields)
+	if yamlConf.Port == 0 {
+		yamlConf.Port = defaultPort
+	}
+	v.check("port", validatePort(yamlConf.Port))
+	v.validateContainer("container", &yamlConf.Container)
+	if yamlConf.Deploy == "" {
+		yamlConf.Deploy = "kubernetes"
+	}
+	v.check("deploy", validateDeployTarget(yamlConf.Deploy))
+	yamlConf.Kubernetes = yamlConf.Kubernetes.withDefaults()
+	v.check("kubernetes", yamlConf.Kubernetes.validate())
+	if yamlConf.CI == nil {
+		yamlConf.CI = StringList{"github"}
+	}
+	v.check("ci", validateCIProviders(yamlConf.CI))
+	v.check("git", yamlConf.Git.validate())
+	yamlConf.Systemd = yamlConf.Systemd.withDefaults(yamlConf.AppName)
+	for i := range yamlConf.Services {
+		v.validateServer(fmt.Sprintf("services[%d]", i), i, &yamlConf.Services[i], yamlConf)
+	}
+	v.validateServerNames(yamlConf.Services)
+	// Servers of a monorepo always share a platform module
+	if len(yamlConf.Services) > 0 && yamlConf.Platform.Module == "" {
+		yamlConf.Platform.Generate = true
+	}
+	yamlConf.Platform = yamlConf.Platform.withDefaults(yamlConf.ModName)
+	v.check("platform", yamlConf.Platform.validate(yamlConf.Modules.Offline))
+	v.warnYamlConfig(yamlConf)
 }
 
-// Reads configuration information from command line flags and
-// returns a FlagConfig object containing application information
-func loadFlagConfig(cmd *cobra.Command) (*FlagConfig, error) {
-	appName, err := cmd.Flags().GetString("app-name")
-	if err != nil {
-		return nil, err
+// Normalizes the methods of the endpoints at path and
+// records every problem found with the endpoints
+func (v *validator) validateEndpointList(path string, endpoints []EndpointDefinition, router string) {
+	for i := range endpoints {
+		e := &endpoints[i]
+		if e.Method = strings.ToUpper(e.Method); e.Method == "" {
+			e.Method = "GET"
+		}
+		v.check(fmt.Sprintf("%s[%d]", path, i), e.validate())
 	}
-	if appName == "" {
-		return nil, fmt.Errorf("no app-name argument was provided")
+	v.check(path, validateEndpoints(endpoints, router))
+}
+
+// Records every problem found with the health checks at path
+func (v *validator) validateHealthCheckList(path string, checks []HealthCheckDefinition) {
+	for i, h := range checks {
+		v.check(fmt.Sprintf("%s[%d]", path, i), h.validate())
 	}
-	modName, err := cmd.Flags().GetString("mod-name")
-	if err != nil {
-		return nil, err
+}
+
+// Records every problem found with the config fields at path,
+// including fields defined more than once
+func (v *validator) validateConfigFieldList(path string, fields []ConfigFieldDefinition) {
+	names := map[string]bool{}
+	for i, c := range fields {
+		field := fmt.Sprintf("%s[%d]", path, i)
+		v.check(field, c.validate())
+		if names[c.Name] {
+			v.report(field+".name", fmt.Sprintf("config field %s is defined more than once", c.Name))
+		}
+		names[c.Name] = true
 	}
-	if modName == "" {
-		modName = appName
+}
+
+// Sets the default profile of the container at path and
+// records every problem found with its settings
+func (v *validator) validateContainer(path string, c *ContainerDefinition) {
+	if c.Profile == "" {
+		c.Profile = "scratch"
 	}
-	dir, err := cmd.Flags().GetString("dir")
-	if err != nil {
-		return nil, err
+	v.check(path+".profile", validateContainerProfile(c.Profile))
+	for i, s := range c.Services {
+		v.check(fmt.Sprintf("%s.services[%d]", path, i), s.validate())
+	}
+}
+
+// Sets the unset settings of the server at path, the index-th server of
+// the monorepo, from the top-level configuration, which has already been
+// validated, and records every problem found with the settings it sets
+func (v *validator) validateServer(path string, index int, s *ServerDefinition, yamlConf *YamlConfig) {
+	if s.Name == "" {
+		v.report(path+".name", "server has no name, which names its directory and module")
+	} else {
+		v.check(path+".name", validateAppName(s.Name))
+	}
+	if s.ModName == "" {
+		s.ModName = yamlConf.ModName + "/" + s.Name
+	}
+	v.check(path+".modName", validateModulePath(s.ModName))
+	if s.Router == "" {
+		s.Router = yamlConf.Router
+	} else if err := validateRouter(s.Router); err != nil {
+		v.check(path+".router", err)
+	} else {
+		v.check(path+".router", yamlConf.Modules.validatePinned(s.Router))
+	}
+	if s.Port == 0 {
+		s.Port = yamlConf.Port + index
+	}
+	v.check(path+".port", validatePort(s.Port))
+	if s.Endpoints == nil {
+		s.Endpoints = yamlConf.Endpoints
+	}
+	v.validateEndpointList(path+".endpoints", s.Endpoints, s.Router)
+	if s.HealthChecks == nil {
+		s.HealthChecks = yamlConf.HealthChecks
+	} else {
+		v.validateHealthCheckList(path+".healthChecks", s.HealthChecks)
+	}
+	if s.ConfigFields == nil {
+		s.ConfigFields = yamlConf.ConfigFields
+	} else {
+		v.validateConfigFieldList(path+".config", s.ConfigFields)
+	}
+	if s.Container.Profile == "" {
+		s.Container.Profile = yamlConf.Container.Profile
+	}
+	// Environment variables of the server take precedence over shared ones
+	env := map[string]string{}
+	for k, value := range yamlConf.Container.Environment {
+		env[k] = value
+	}
+	for k, value := range s.Container.Environment {
+		env[k] = value
+	}
+	s.Container.Environment = env
+	if s.Container.Services == nil {
+		s.Container.Services = yamlConf.Container.Services
+	}
+	v.validateContainer(path+".container", &s.Container)
+}
+
+// Records servers of the monorepo whose names or ports conflict with
+// each other, the services run alongside them or the platform module
+func (v *validator) validateServerNames(servers []ServerDefinition) {
+	names, ports := map[string]bool{}, map[int]string{}
+	for _, s := range servers {
+		for _, dep := range s.Container.Services {
+			names[dep.Name] = true
+		}
+	}
+	for i, s := range servers {
+		path := fmt.Sprintf("services[%d]", i)
+		switch {
+		case s.Name == "internal":
+			v.report(path+".name", "server name internal is reserved for the platform module")
+		case names[s.Name]:
+			v.report(path+".name", fmt.Sprintf("server %s has the same name as another server or container service", s.Name))
+		}
+		names[s.Name] = true
+		if other, ok := ports[s.Port]; ok {
+			v.report(path+".port", fmt.Sprintf("port %d is already used by server %s", s.Port, other))
+		}
+		ports[s.Port] = s.Name
 	}
-	return &FlagConfig{
-		AppName:   appName,
-		ModName:   modName,
-		Directory: dir,
-	}, nil
+}
+
+// Records settings which are valid but have no effect
+// or are unlikely to be intended
+func (v *validator) warnYamlConfig(yamlConf *YamlConfig) {
+	if v.has("kubernetes") && yamlConf.Deploy != "kubernetes" && yamlConf.Deploy != "helm" {
+		v.warn("kubernetes", fmt.Sprintf("kubernetes settings are ignored when deploying to %s", yamlConf.Deploy))
+	}
+	if v.has("systemd") && yamlConf.Deploy != "systemd" {
+		v.warn("systemd", fmt.Sprintf("systemd settings are ignored when deploying to %s", yamlConf.Deploy))
+	}
+	for i, c := range yamlConf.ConfigFields {
+		if c.Secret && c.Default != "" {
+			v.warn(fmt.Sprintf("config[%d].default", i), fmt.Sprintf("secret config field %s has a default, which is written to generated files", c.Name))
+		}
+	}
+	if yamlConf.Git.Author != "" && !yamlConf.Git.Init {
+		v.warn("git.author", "git author is ignored unless `init` is set")
+	}
+	if backend, ok := routerBackends[yamlConf.Router]; ok && yamlConf.Modules.Vendor && backend.Package == "" {
+		v.warn("modules.vendor", fmt.Sprintf("router %s has no dependencies to vendor", yamlConf.Router))
+	}
+	if len(yamlConf.Services) > 0 && yamlConf.Modules.Vendor {
+		v.warn("modules.vendor", "vendor/ directories of servers are ignored by the go workspace, build with GOWORK=off to use them")
+	}
+	if v.has("platform.version") && yamlConf.Platform.Generate {
+		v.warn("platform.version", "platform version is ignored when generating the platform module")
+	}
+}
+
+// Matches names which can be used for directories, container
+// images and Kubernetes resources, (i.e. payments-api)
+var appNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Checks that the application name can be used to name the
+// project directory, binary, image and deployment resources
+func validateAppName(appName string) error {
+	if len(appName) > 63 || !appNameRegex.MatchString(appName) {
+		return fmt.Errorf("app name %q must be at most 63 lowercase letters, digits or hyphens, starting and ending with a letter or digit", appName)
+	}
+	return nil
+}
+
+// Checks that the generated server can listen on the given port
+func validatePort(port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("port %d must be between 1 and 65535", port)
+	}
+	return nil
+}
+
+// Checks that the container can be built from the given profile
+func validateContainerProfile(profile string) error {
+	for _, p := range containerProfiles {
+		if profile == p {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported container profile %q, must be one of %s", profile, strings.Join(containerProfiles, ", "))
+}
+
+// Checks that deployment files can be generated for the given target
+func validateDeployTarget(deploy string) error {
+	for _, d := range deployTargets {
+		if deploy == d {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported deploy target %q, must be one of %s", deploy, strings.Join(deployTargets, ", "))
+}
+
+// Checks that pipelines can be generated for the given CI providers
+func validateCIProviders(providers []string) error {
+	for _, p := range providers {
+		if p == "none" {
+			if len(providers) > 1 {
+				return fmt.Errorf("ci provider none cannot be combined with other providers")
+			}
+			continue
+		}
+		supported := false
+		for _, c := range ciProviders {
+			supported = supported || p == c
+		}
+		if !supported {
+			return fmt.Errorf("unsupported ci provider %q, must be one of none, %s", p, strings.Join(ciProviders, ", "))
+		}
+	}
+	return nil
 }
 
 // Returns an alphanumeric camelcase representation of a
 // path-style endpoint (i.e. /v1/healthcheck -> v1Healthcheck)
 func capitalizeAfterSlash(s string) string {
 	var result string
-	capitalize := true
+	capitalize := false
 
 	for _, c := range s {
-		if c == '/' {
-			capitalize = true
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			capitalize = result != ""
 		} else {
 			if capitalize {
 				result += strings.ToUpper(string(c))
@@ -196,3 +1307,32 @@ func capitalizeAfterSlash(s string) string {
 
 	return result
 }
+
+// Returns a Go source representation of a duration
+// (i.e. 2s -> 2 * time.Second, 500ms -> 500 * time.Millisecond)
+func durationLiteral(d time.Duration) string {
+	switch {
+	case d%time.Second == 0:
+		return fmt.Sprintf("%d * time.Second", d/time.Second)
+	case d%time.Millisecond == 0:
+		return fmt.Sprintf("%d * time.Millisecond", d/time.Millisecond)
+	default:
+		return fmt.Sprintf("time.Duration(%d)", d)
+	}
+}
+
+// Returns a kebab-case representation of a camelCase
+// name (i.e. shutdownDelay -> shutdown-delay)
+func kebabCase(s string) string {
+	var result string
+	for i, c := range s {
+		if c >= 'A' && c <= 'Z' {
+			if i > 0 {
+				result += "-"
+			}
+			c += 'a' - 'A'
+		}
+		result += string(c)
+	}
+	return result
+}
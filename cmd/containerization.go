@@ -1,60 +1,177 @@
 package cmd
 
-var DOCKERFILE_BASE = `# Stage 1: Builder
-FROM golang:latest AS builder
+// Pinned alpine image used to collect certificates and timezone data
+const ALPINE_IMAGE = "docker.io/library/alpine@sha256:686d8c9dfa6f3ccfc8230bc3178d23f84eeaf7e457f36f271ab1acc53015037c"
+
+// Runtime images which generated containers can be built from
+var containerProfiles = []string{"scratch", "distroless", "alpine"}
+
+var DOCKERFILE_BASE = `# syntax=docker/dockerfile:1
+
+# Stage 1: Builder
+FROM golang:{{.GoVersion}} AS builder
 
 WORKDIR /src
 
-COPY ./go.mod /src/
-COPY ./go.sum /src/
+# go.sum is absent when the server has no dependencies
+COPY ./{{.ServerDir}}go.mod ./{{.ServerDir}}go.sum* /src/
+{{- with .PlatformDir}}
+
+# Generated platform module, which go.mod replaces the platform module with
+COPY ./{{$.PlatformContextDir}} {{$.PlatformImageDir}}
+{{- end}}
+{{- if .Vendor}}
+
+# Build from vendored modules without downloading them
+COPY ./{{.ServerDir}}vendor /src/vendor
+{{- else}}
+
+# Cache downloaded modules between builds
+RUN --mount=type=cache,target=/go/pkg/mod \
+    go mod download
+{{- end}}
 
-RUN go mod download
+# Build information injected into the binary through -ldflags
+ARG VERSION=dev
+ARG COMMIT=unknown
+ARG BUILD_TIME=unknown
+# Set to false to skip unit tests, (i.e. when they have already run in CI)
+ARG RUN_TESTS=true
 
-COPY ./cmd/api /src
+COPY ./{{.ServerDir}}cmd/api /src
 
-# Run unit tests before building to sto build if tests fail
-RUN go test -v
+# Run unit tests before building to stop build if tests fail
+RUN --mount=type=cache,target=/go/pkg/mod \
+    --mount=type=cache,target=/root/.cache/go-build \
+    if [ "${RUN_TESTS}" = "true" ]; then go test -v; fi
 
-RUN CGO_ENABLED=0 GOOS=linux go build -o entrypoint
+# Build a static, stripped binary without local file system paths
+RUN --mount=type=cache,target=/go/pkg/mod \
+    --mount=type=cache,target=/root/.cache/go-build \
+    CGO_ENABLED=0 GOOS=linux go build -trimpath \
+    -ldflags="-s -w -X main.version=${VERSION} -X main.commit=${COMMIT} -X main.buildTime=${BUILD_TIME}" \
+    -o entrypoint
 
+{{- if eq .ContainerProfile "scratch"}}
 
-# Stage 2: Certs
-FROM docker.io/library/alpine@sha256:686d8c9dfa6f3ccfc8230bc3178d23f84eeaf7e457f36f271ab1acc53015037c AS tools
+
+# Stage 2: Certs and timezone data
+FROM {{.AlpineImage}} AS tools
 
 RUN apk add --no-cache \
-    ca-certificates
+    ca-certificates \
+    tzdata
 
 # Stage 3: Runner
 FROM scratch
 
 COPY --from=tools /etc/ssl/certs/ca-certificates.crt /etc/ssl/certs/ca-certificates.crt
+COPY --from=tools /usr/share/zoneinfo /usr/share/zoneinfo
+COPY --from=builder /src/entrypoint /
+
+# Run as an unprivileged user, using a numeric id as scratch has no /etc/passwd
+USER 65532:65532
+{{- else if eq .ContainerProfile "distroless"}}
+
+
+# Stage 2: Runner, which already contains certs, timezone data and a nonroot user
+FROM gcr.io/distroless/static-debian12:nonroot
+
 COPY --from=builder /src/entrypoint /
 
-EXPOSE 4000
+USER nonroot:nonroot
+{{- else}}
+
+
+# Stage 2: Runner
+FROM {{.AlpineImage}}
 
-ENTRYPOINT [ "./entrypoint" ]
+RUN apk add --no-cache \
+    ca-certificates \
+    tzdata \
+    && addgroup -S -g 65532 app \
+    && adduser -S -u 65532 -G app -H -s /sbin/nologin app
+
+COPY --from=builder /src/entrypoint /
+
+# Run as an unprivileged user
+USER app:app
+{{- end}}
+
+# The server doesn't write to its file system, so the container can be run
+# with a read-only root file system, (i.e. docker run --read-only)
+EXPOSE {{.Port}}
+
+# Query the liveness endpoint through the server binary as the image may have no shell
+HEALTHCHECK --interval=30s --timeout=5s --start-period=5s --retries=3 \
+    CMD [ "/entrypoint", "-healthcheck" ]
+
+ENTRYPOINT [ "/entrypoint" ]
 `
 
 var DOCKER_COMPOSE_BASE = `version: "3.9"
 
 services:
-  server:
-    image: server
-    build: .
+  {{.AppName}}:
+    image: {{.AppName}}
+    build:
+      context: {{.BuildContext}}
+{{- with .ServerDir}}
+      dockerfile: {{.}}Dockerfile
+{{- end}}
+      args:
+        VERSION: ${VERSION:-dev}
+        COMMIT: ${COMMIT:-unknown}
+        BUILD_TIME: ${BUILD_TIME:-unknown}
+        RUN_TESTS: ${RUN_TESTS:-true}
+    ports:
+      - "{{.Port}}:{{.Port}}"
+    # Harden the runtime container, mounting a writable /tmp only
+    read_only: true
+    tmpfs:
+      - /tmp
+    cap_drop:
+      - ALL
+    security_opt:
+      - no-new-privileges:true
+    environment:
+{{- range $name, $value := .Environment}}
+      {{$name}}: {{printf "%q" $value}}
+{{- end}}
+{{- if .Services}}
+    depends_on:
+{{- range .Services}}
+      - {{.Name}}
+{{- end}}
+{{- end}}
+{{- range .Services}}
+
+  {{.Name}}:
+    image: {{.Image}}
+{{- if .Ports}}
     ports:
-      - "4000:4000"
+{{- range .Ports}}
+      - {{printf "%q" .}}
+{{- end}}
+{{- end}}
+{{- if .Environment}}
+    environment:
+{{- range $name, $value := .Environment}}
+      {{$name}}: {{printf "%q" $value}}
+{{- end}}
+{{- end}}
+{{- end}}
 `
 
 // Creates Dockerfile and docker-compose.yaml
-func GenerateContainerizationFiles(target string) error {
+func GenerateContainerizationFiles(target string, data TemplateData) error {
 	// Create dockerfile
 	dockerfile, err := createFile("Dockerfile", target)
 	if err != nil {
 		return err
 	}
 	// Write dockerfile content to newly created file
-	// ToDo: Add templating here where necessary
-	if err := writeFile(dockerfile, DOCKERFILE_BASE); err != nil {
+	if err := writeTemplate(dockerfile, DOCKERFILE_BASE, data); err != nil {
 		return err
 	}
 	// Create docker-compose file
@@ -63,8 +180,7 @@ func GenerateContainerizationFiles(target string) error {
 		return err
 	}
 	// Write docker-compose content to newly created file
-	// ToDo: Add templating here where necessary
-	if err := writeFile(dockerComposeFile, DOCKER_COMPOSE_BASE); err != nil {
+	if err := writeTemplate(dockerComposeFile, DOCKER_COMPOSE_BASE, data); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,259 @@
+package cmd
+
+import "path/filepath"
+
+// Targets which the generated server can be deployed to
+var deployTargets = []string{"none", "kubernetes", "helm", "systemd"}
+
+var K8S_DEPLOYMENT_BASE = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.AppName}}
+  labels:
+    app.kubernetes.io/name: {{.AppName}}
+spec:
+  replicas: {{.Kubernetes.Replicas}}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{.AppName}}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{.AppName}}
+    spec:
+      # Leave time for the server to fail readiness and drain in-flight requests
+      terminationGracePeriodSeconds: 30
+      securityContext:
+        runAsNonRoot: true
+        runAsUser: 65532
+        runAsGroup: 65532
+        seccompProfile:
+          type: RuntimeDefault
+      containers:
+        - name: {{.AppName}}
+          image: {{.AppName}}
+          ports:
+            - name: http
+              containerPort: {{.Port}}
+          envFrom:
+            - configMapRef:
+                name: {{.AppName}}-config
+            - secretRef:
+                name: {{.AppName}}-secret
+          livenessProbe:
+            httpGet:
+              path: /v1/healthcheck
+              port: http
+            initialDelaySeconds: 5
+            periodSeconds: 10
+          readinessProbe:
+            httpGet:
+              path: /v1/readycheck
+              port: http
+            periodSeconds: 5
+            failureThreshold: 2
+          resources:
+            requests:
+              cpu: {{.Kubernetes.Requests.CPU}}
+              memory: {{.Kubernetes.Requests.Memory}}
+            limits:
+              cpu: {{.Kubernetes.Limits.CPU}}
+              memory: {{.Kubernetes.Limits.Memory}}
+          securityContext:
+            allowPrivilegeEscalation: false
+            readOnlyRootFilesystem: true
+            capabilities:
+              drop:
+                - ALL
+          volumeMounts:
+            - name: tmp
+              mountPath: /tmp
+      volumes:
+        - name: tmp
+          emptyDir: {}
+`
+
+var K8S_SERVICE_BASE = `apiVersion: v1
+kind: Service
+metadata:
+  name: {{.AppName}}
+  labels:
+    app.kubernetes.io/name: {{.AppName}}
+spec:
+  type: ClusterIP
+  selector:
+    app.kubernetes.io/name: {{.AppName}}
+  ports:
+    - name: http
+      port: 80
+      targetPort: http
+`
+
+var K8S_CONFIGMAP_BASE = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{.AppName}}-config
+  labels:
+    app.kubernetes.io/name: {{.AppName}}
+data:
+{{- range .PlainConfigFields}}
+  {{.GenerateManifestEntry}}
+{{- end}}
+`
+
+var K8S_SECRET_BASE = `# Template for the secret settings of the server, fill in values
+# locally or through your secret manager and never commit them
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{.AppName}}-secret
+  labels:
+    app.kubernetes.io/name: {{.AppName}}
+type: Opaque
+stringData:
+{{- range .SecretConfigFields}}
+  {{.GenerateManifestEntry}}
+{{- else}} {}
+{{- end}}
+`
+
+var K8S_HPA_BASE = `apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{.AppName}}
+  labels:
+    app.kubernetes.io/name: {{.AppName}}
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{.AppName}}
+  minReplicas: {{.Kubernetes.MinReplicas}}
+  maxReplicas: {{.Kubernetes.MaxReplicas}}
+  metrics:
+    - type: Resource
+      resource:
+        name: cpu
+        target:
+          type: Utilization
+          averageUtilization: {{.Kubernetes.TargetCPUUtilization}}
+`
+
+var K8S_PDB_BASE = `apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: {{.AppName}}
+  labels:
+    app.kubernetes.io/name: {{.AppName}}
+spec:
+  maxUnavailable: 1
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{.AppName}}
+`
+
+var KUSTOMIZATION_BASE = `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - deployment.yaml
+  - service.yaml
+  - configmap.yaml
+  - secret.yaml
+  - hpa.yaml
+  - pdb.yaml
+`
+
+var KUSTOMIZATION_DEV = `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namespace: {{.AppName}}-dev
+resources:
+  - ../../base
+images:
+  - name: {{.AppName}}
+    newTag: dev
+# Run a single replica in development
+patches:
+  - target:
+      kind: Deployment
+      name: {{.AppName}}
+    patch: |-
+      - op: replace
+        path: /spec/replicas
+        value: 1
+  - target:
+      kind: HorizontalPodAutoscaler
+      name: {{.AppName}}
+    patch: |-
+      - op: replace
+        path: /spec/minReplicas
+        value: 1
+      - op: replace
+        path: /spec/maxReplicas
+        value: 1
+`
+
+var KUSTOMIZATION_PROD = `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namespace: {{.AppName}}
+resources:
+  - ../../base
+# Pin the released image, (i.e. kustomize edit set image {{.AppName}}=registry/{{.AppName}}:v1.0.0)
+images:
+  - name: {{.AppName}}
+    newTag: latest
+`
+
+// Creates Kubernetes manifests with a kustomize base and overlays
+func GenerateKubernetesManifests(target string, data TemplateData) error {
+	k8s := filepath.Join(target, "remote", "kubernetes")
+	if err := createDirectories(k8s, "base", "overlays/dev", "overlays/prod"); err != nil {
+		return err
+	}
+	manifests := [][]string{
+		{"base/deployment.yaml", K8S_DEPLOYMENT_BASE},
+		{"base/service.yaml", K8S_SERVICE_BASE},
+		{"base/configmap.yaml", K8S_CONFIGMAP_BASE},
+		{"base/secret.yaml", K8S_SECRET_BASE},
+		{"base/hpa.yaml", K8S_HPA_BASE},
+		{"base/pdb.yaml", K8S_PDB_BASE},
+		{"base/kustomization.yaml", KUSTOMIZATION_BASE},
+		{"overlays/dev/kustomization.yaml", KUSTOMIZATION_DEV},
+		{"overlays/prod/kustomization.yaml", KUSTOMIZATION_PROD},
+	}
+	for _, m := range manifests {
+		f, err := createFile(m[0], k8s)
+		if err != nil {
+			return err
+		}
+		if err := writeTemplate(f, m[1], data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Creates deployment files in the remote directory for the configured target
+func GenerateDeploymentFiles(target string, data TemplateData) error {
+	switch data.Deploy {
+	case "kubernetes":
+		return GenerateKubernetesManifests(target, data)
+	case "helm":
+		return GenerateHelmChart(target, data)
+	case "systemd":
+		return GenerateSystemdBundle(target, data)
+	}
+	return nil
+}
+
+// Returns README.md instructions for deploying with the configured target
+func DeploymentReadme(data TemplateData) string {
+	switch data.Deploy {
+	case "kubernetes":
+		return "Kubernetes manifests are generated in `remote/kubernetes` as a kustomize base with `dev` and `prod` overlays. Fill in `base/secret.yaml`, then deploy with `kubectl apply -k remote/kubernetes/overlays/dev`.\n"
+	case "helm":
+		return "A Helm chart is generated in `remote/chart`. Deploy with `helm install " + data.AppName + " remote/chart --set-string secretEnv.NAME=value` for each secret setting.\n"
+	case "systemd":
+		return "A systemd unit, environment file and Caddy and nginx reverse proxy configurations are generated in `remote/systemd`. Run `make build`, then `sudo ./remote/systemd/setup.sh` on the host to install and start the server, and fill in `/etc/" + data.AppName + "/" + data.AppName + ".env`.\n"
+	}
+	return ""
+}
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Configurations which configuration files can extend by name,
+// (i.e. extends: api)
+//
+//go:embed presets
+var presetFiles embed.FS
+
+// Returns the names of the built-in presets
+func presetNames() []string {
+	entries, err := presetFiles.ReadDir("presets")
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Keys identifying the items of lists which are merged item by item
+// when extending a configuration, rather than replaced, by path
+var mergeKeys = map[string]func(item *yaml.Node) string{
+	"endpoints": func(item *yaml.Node) string {
+		method := strings.ToUpper(mappingValue(item, "method"))
+		if method == "" {
+			method = "GET"
+		}
+		return method + " " + mappingValue(item, "path")
+	},
+	"healthChecks": func(item *yaml.Node) string {
+		return mappingValue(item, "name")
+	},
+	"config": func(item *yaml.Node) string {
+		return mappingValue(item, "name")
+	},
+	"container.services": func(item *yaml.Node) string {
+		return mappingValue(item, "name")
+	},
+	"services": func(item *yaml.Node) string {
+		return mappingValue(item, "name")
+	},
+}
+
+// Returns the value of the scalar at key in the mapping, if any
+func mappingValue(n *yaml.Node, key string) string {
+	if _, value := mappingEntry(n, key); value != nil && value.Kind == yaml.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
+
+// documentLoader reads configuration files and the
+// configurations they extend into a single document
+type documentLoader struct {
+	files map[*yaml.Node]string // Files nodes were read from
+	chain []string              // Configurations being loaded, to detect cycles
+}
+
+// Reads the configuration file, or stdin if filename is -, merged over the
+// configurations it extends, returning the merged document and the files
+// each of its nodes were read from. The format of the file is detected
+// from its extension unless given
+func readConfigDocument(filename, format string) (*yaml.Node, map[*yaml.Node]string, error) {
+	l := &documentLoader{files: map[*yaml.Node]string{}}
+	var content []byte
+	var err error
+	if filename == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if format, err = configFormat(filename, format); err != nil {
+		return nil, nil, err
+	}
+	// Files extended by stdin are relative to the working directory
+	doc, err := l.load(configFileName(filename), filename, format, content)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, l.files, nil
+}
+
+// Parses a configuration named name, which is either a path or preset,
+// and merges it over the configurations it extends
+func (l *documentLoader) load(name, id, format string, content []byte) (*yaml.Node, error) {
+	for _, loading := range l.chain {
+		if loading == id {
+			return nil, fmt.Errorf("%s: extends itself through %s", name, strings.Join(append(l.chain, id), " -> "))
+		}
+	}
+	l.chain = append(l.chain, id)
+	defer func() { l.chain = l.chain[:len(l.chain)-1] }()
+
+	doc, err := parseConfigDocument(content, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	l.record(doc, name)
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return doc, nil
+	}
+	root := doc.Content[0]
+	var bases []string
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "extends" {
+			continue
+		}
+		extends := root.Content[i+1]
+		switch {
+		case extends.Kind == yaml.ScalarNode && extends.ShortTag() == "!!str":
+			bases = append(bases, extends.Value)
+		case extends.Kind == yaml.SequenceNode:
+			for _, b := range extends.Content {
+				if b.Kind != yaml.ScalarNode || b.ShortTag() != "!!str" {
+					return nil, fmt.Errorf("%s:%d:%d: extends: expected a file or preset name", name, b.Line, b.Column)
+				}
+				bases = append(bases, b.Value)
+			}
+		case extends.ShortTag() != "!!null":
+			return nil, fmt.Errorf("%s:%d:%d: extends: expected a file or preset name, or a list of them", name, extends.Line, extends.Column)
+		}
+		// The merged document no longer extends anything
+		root.Content = append(root.Content[:i], root.Content[i+2:]...)
+		break
+	}
+	// Later bases take precedence over earlier ones, and the
+	// configuration itself over all of its bases
+	var merged *yaml.Node
+	for _, base := range bases {
+		b, err := l.loadBase(name, id, base)
+		if err != nil {
+			return nil, err
+		}
+		if len(b.Content) == 0 {
+			continue
+		}
+		if merged == nil {
+			merged = b.Content[0]
+			continue
+		}
+		merged = l.merge("", merged, b.Content[0])
+	}
+	if merged != nil {
+		doc.Content[0] = l.merge("", merged, root)
+	}
+	return doc, nil
+}
+
+// Loads a configuration extended by the configuration named name, which
+// is a preset if it has no extension of a configuration format, or a path
+// relative to the extending file otherwise. Presets can only extend other presets
+func (l *documentLoader) loadBase(name, id, base string) (*yaml.Node, error) {
+	if !isConfigFile(base) {
+		content, err := presetFiles.ReadFile(path.Join("presets", base+".yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: extends unknown preset %q, must be one of %s or a path to a .yaml, .json or .toml file", name, base, strings.Join(presetNames(), ", "))
+		}
+		return l.load("preset "+base, "preset "+base, "yaml", content)
+	}
+	if strings.HasPrefix(id, "preset ") {
+		return nil, fmt.Errorf("%s: presets can only extend other presets", name)
+	}
+	file := base
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(filepath.Dir(id), base)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: extends %s: %w", name, base, err)
+	}
+	format, err := configFormat(file, "")
+	if err != nil {
+		return nil, err
+	}
+	return l.load(file, filepath.Clean(file), format, content)
+}
+
+// Records the file the node and its children were read from
+func (l *documentLoader) record(n *yaml.Node, file string) {
+	l.files[n] = file
+	for _, c := range n.Content {
+		l.record(c, file)
+	}
+}
+
+// Returns the setting of a configuration merged over the setting
+// of the configuration it extends. Mappings are merged key by key, lists
+// with merge keys item by item, and any other value is replaced
+func (l *documentLoader) merge(setting string, base, over *yaml.Node) *yaml.Node {
+	switch {
+	case base.Kind == yaml.MappingNode && over.Kind == yaml.MappingNode:
+		merged := &yaml.Node{Kind: yaml.MappingNode, Tag: over.Tag, Line: over.Line, Column: over.Column}
+		l.files[merged] = l.files[over]
+		overrides := map[string]*yaml.Node{}
+		for i := 0; i+1 < len(over.Content); i += 2 {
+			overrides[over.Content[i].Value] = over.Content[i+1]
+		}
+		seen := map[string]bool{}
+		for i := 0; i+1 < len(base.Content); i += 2 {
+			key, value := base.Content[i], base.Content[i+1]
+			if o, ok := overrides[key.Value]; ok {
+				key, _ = mappingEntry(over, key.Value)
+				value = l.merge(joinPath(setting, key.Value), value, o)
+			}
+			seen[key.Value] = true
+			merged.Content = append(merged.Content, key, value)
+		}
+		for i := 0; i+1 < len(over.Content); i += 2 {
+			if !seen[over.Content[i].Value] {
+				merged.Content = append(merged.Content, over.Content[i], over.Content[i+1])
+			}
+		}
+		return merged
+	case base.Kind == yaml.SequenceNode && over.Kind == yaml.SequenceNode && mergeKeys[setting] != nil:
+		keyOf := mergeKeys[setting]
+		merged := &yaml.Node{Kind: yaml.SequenceNode, Tag: over.Tag, Line: over.Line, Column: over.Column}
+		l.files[merged] = l.files[over]
+		merged.Content = append(merged.Content, base.Content...)
+		indexes := map[string]int{}
+		for i, item := range base.Content {
+			if key := keyOf(item); key != "" {
+				indexes[key] = i
+			}
+		}
+		for _, item := range over.Content {
+			if i, ok := indexes[keyOf(item)]; ok && keyOf(item) != "" {
+				merged.Content[i] = l.merge(setting+"[]", base.Content[i], item)
+				continue
+			}
+			merged.Content = append(merged.Content, item)
+		}
+		return merged
+	}
+	return over
+}
+
+// Returns the key and value nodes of key in the mapping, or nil if unset
+func mappingEntry(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
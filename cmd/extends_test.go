@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseYaml is a helper function that parses a YAML document into its root node
+func parseYaml(t *testing.T, content string) *yaml.Node {
+	t.Helper() // Mark the function as test helper
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc.Content[0]
+}
+
+// TestMerge checks that settings are merged over the settings of the
+// configuration they extend, with lists of items with merge keys merged
+// item by item
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		over     string
+		expected string
+	}{
+		{"scalars replaced", "router: chi\nport: 4000\n", "port: 8080\n", "router: chi\nport: 8080\n"},
+		{"mappings merged", "container:\n    profile: alpine\n    environment:\n        A: \"1\"\n", "container:\n    environment:\n        B: \"2\"\n", "container:\n    profile: alpine\n    environment:\n        A: \"1\"\n        B: \"2\"\n"},
+		{"mapping replaced by scalar", "container:\n    profile: alpine\n", "container: null\n", "container: null\n"},
+		{"lists without merge keys replaced", "ci:\n    - github\n    - gitlab\n", "ci:\n    - none\n", "ci:\n    - none\n"},
+		{"endpoints merged by method and path", "endpoints:\n    - path: /v1/a\n      description: a\n    - path: /v1/a\n      method: post\n", "endpoints:\n    - path: /v1/a\n      method: get\n      description: b\n    - path: /v1/b\n", "endpoints:\n    - path: /v1/a\n      description: b\n      method: get\n    - path: /v1/a\n      method: post\n    - path: /v1/b\n"},
+		{"health checks merged by name", "healthChecks:\n    - name: tmp\n      type: disk\n      target: /tmp\n", "healthChecks:\n    - name: tmp\n      target: /var/tmp\n", "healthChecks:\n    - name: tmp\n      type: disk\n      target: /var/tmp\n"},
+		{"config merged by name", "config:\n    - name: dbDsn\n      type: string\n", "config:\n    - name: maxWorkers\n      type: int\n", "config:\n    - name: dbDsn\n      type: string\n    - name: maxWorkers\n      type: int\n"},
+		{"container services merged by name", "container:\n    services:\n        - name: postgres\n          image: postgres:15\n", "container:\n    services:\n        - name: postgres\n          image: postgres:16\n", "container:\n    services:\n        - name: postgres\n          image: postgres:16\n"},
+		{"servers merged by name", "services:\n    - name: billing\n      port: 4000\n      ci:\n        - github\n", "services:\n    - name: billing\n      ci:\n        - none\n", "services:\n    - name: billing\n      port: 4000\n      ci:\n        - none\n"},
+		{"items without merge keys appended", "healthChecks:\n    - type: disk\n", "healthChecks:\n    - type: disk\n", "healthChecks:\n    - type: disk\n    - type: disk\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &documentLoader{files: map[*yaml.Node]string{}}
+			merged := l.merge("", parseYaml(t, tt.base), parseYaml(t, tt.over))
+			out, err := yaml.Marshal(merged)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, out)
+			}
+		})
+	}
+}
+
+// TestReadConfigDocument checks that configurations are read merged over
+// the files and presets they extend, and that the configurations which
+// can't be extended are reported
+func TestReadConfigDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string // Files of the test, the first extending the others
+		expected string            // Settings of the merged document, or the problem found
+		fails    bool
+	}{
+		{"file", map[string]string{"talbot.yaml": "extends: base.yaml\nport: 8080\n", "base.yaml": "router: chi\nport: 4000\n"}, "router: chi\nport: 8080\n", false},
+		{"relative to the extending file", map[string]string{"talbot.yaml": "extends: shared/base.yaml\n", "shared/base.yaml": "extends: ../root.json\nport: 4000\n", "root.json": `{"router": "gin"}`}, "router: gin\nport: 4000\n", false},
+		{"later files take precedence", map[string]string{"talbot.yaml": "extends: [a.yaml, b.toml]\n", "a.yaml": "router: chi\nport: 4000\n", "b.toml": "port = 8080\n"}, "router: chi\nport: 8080\n", false},
+		{"preset", map[string]string{"talbot.yaml": "extends: minimal\nci: github\n"}, "router: stdlib\ncontainer:\n    profile: scratch\ndeploy: none\nci: github\n", false},
+		{"file extending preset", map[string]string{"talbot.yaml": "extends: base.yaml\ndeploy: systemd\n", "base.yaml": "extends: minimal\n"}, "router: stdlib\ncontainer:\n    profile: scratch\ndeploy: systemd\nci: none\n", false},
+		{"cycle", map[string]string{"talbot.yaml": "extends: a.yaml\n", "a.yaml": "extends: b.yaml\n", "b.yaml": "extends: a.yaml\n"}, "extends itself through", true},
+		{"itself", map[string]string{"talbot.yaml": "extends: talbot.yaml\n"}, "extends itself through", true},
+		{"missing file", map[string]string{"talbot.yaml": "extends: missing.yaml\n"}, "extends missing.yaml", true},
+		{"unknown preset", map[string]string{"talbot.yaml": "extends: huge\n"}, `extends unknown preset "huge"`, true},
+		{"invalid extends", map[string]string{"talbot.yaml": "extends: 1\n"}, "extends: expected a file or preset name", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				file := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			doc, _, err := readConfigDocument(filepath.Join(dir, "talbot.yaml"), "")
+			if tt.fails {
+				if err == nil || !strings.Contains(err.Error(), tt.expected) {
+					t.Errorf("Expected problem %q, got %v.", tt.expected, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Compare the settings regardless of how they are written
+			var got, expected any
+			if err := doc.Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if err := yaml.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected %v, got %v.", expected, got)
+			}
+		})
+	}
+
+	// Presets are built in, so they can't refer to files
+	l := &documentLoader{files: map[*yaml.Node]string{}}
+	if _, err := l.loadBase("preset api", "preset api", "base.yaml"); err == nil || !strings.Contains(err.Error(), "presets can only extend other presets") {
+		t.Errorf("Expected preset extending a file to be rejected, got %v.", err)
+	}
+}
+
+// TestExtendsPositions checks that problems with values read from an
+// extended file are located in that file
+func TestExtendsPositions(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "talbot.yaml")
+	if err := os.WriteFile(filename, []byte("extends: base.yaml\nappName: svc\nrouter: nope\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "base.yaml"), []byte("# Shared settings\nport: 70000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, v, err := readConfig(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"base.yaml:2:1 port", "talbot.yaml:3:1 router"}
+	if got := locations(v.errs); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected problems at %v, got %v.", expected, v.errs)
+	}
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"go/format"
 	"os"
 	"path/filepath"
+	"text/template"
 )
 
 // Creates an empty file in a specified path
@@ -26,3 +29,24 @@ func writeFile(file *os.File, content string) error {
 	_, err := file.Write([]byte(content + "\n"))
 	return err
 }
+
+// Renders a template with the given data and writes it to a given file,
+// formatting the result first if the file contains Go source code
+func writeTemplate(file *os.File, content string, data any) error {
+	tmpl, err := template.New(filepath.Base(file.Name())).Parse(content)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	rendered := buf.Bytes()
+	if filepath.Ext(file.Name()) == ".go" {
+		if rendered, err = format.Source(rendered); err != nil {
+			return fmt.Errorf("couldn't format %s: %w", file.Name(), err)
+		}
+	}
+	_, err = file.Write(rendered)
+	return err
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats configuration files can be written in
+var configFormats = []string{"yaml", "json", "toml"}
+
+// Returns the format of the configuration file from its extension,
+// unless given explicitly. Configurations read from stdin are YAML
+// unless given explicitly
+func configFormat(filename, format string) (string, error) {
+	if format != "" {
+		for _, f := range configFormats {
+			if format == f {
+				return format, nil
+			}
+		}
+		return "", fmt.Errorf("unsupported config format %q, must be one of %s", format, strings.Join(configFormats, ", "))
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return "json", nil
+	case ".toml":
+		return "toml", nil
+	}
+	return "yaml", nil
+}
+
+// Returns whether the name refers to a configuration file rather than
+// a preset, by having the extension of a supported format
+func isConfigFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json", ".toml":
+		return true
+	}
+	return false
+}
+
+// Parses a configuration in the given format into a YAML document,
+// so that every format is checked and decoded in the same way
+func parseConfigDocument(content []byte, format string) (*yaml.Node, error) {
+	var doc yaml.Node
+	switch format {
+	case "toml":
+		root, err := parseTOML(content)
+		if err != nil {
+			return nil, err
+		}
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}, Line: 1, Column: 1}
+	default:
+		// JSON documents are YAML documents too
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, err
+		}
+	}
+	return &doc, nil
+}
+
+// Parses a TOML document into a YAML mapping, keeping the
+// positions of keys and values
+func parseTOML(content []byte) (*yaml.Node, error) {
+	// Decode the document first, which reports invalid
+	// documents that the parser alone doesn't
+	var check map[string]any
+	if err := toml.Unmarshal(content, &check); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, column := decodeErr.Position()
+			return nil, fmt.Errorf("line %d, column %d: %s", line, column, decodeErr.Error())
+		}
+		// Keys defined twice are located while converting
+		if _, convErr := convertTOML(content); convErr != nil {
+			return nil, convErr
+		}
+		return nil, err
+	}
+	return convertTOML(content)
+}
+
+// Converts a valid TOML document into a YAML mapping
+func convertTOML(content []byte) (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}
+	table := root
+	declared := map[*yaml.Node]bool{} // Tables declared with [table]
+	p := unstable.Parser{}
+	p.Reset(content)
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.KeyValue:
+			if err := setTOMLValue(&p, table, expr); err != nil {
+				return nil, err
+			}
+		case unstable.Table, unstable.ArrayTable:
+			keys := tomlKeys(&p, expr.Key())
+			parent, err := tomlTable(root, keys[:len(keys)-1])
+			if err != nil {
+				return nil, err
+			}
+			last := keys[len(keys)-1]
+			_, value := mappingEntry(parent, last.Value)
+			if expr.Kind == unstable.Table {
+				if value == nil {
+					value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: last.Line, Column: last.Column}
+					parent.Content = append(parent.Content, last, value)
+				} else if declared[value] {
+					return nil, fmt.Errorf("line %d, column %d: table %s is already defined", last.Line, last.Column, last.Value)
+				}
+				declared[value] = true
+				table = value
+				continue
+			}
+			if value == nil {
+				value = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: last.Line, Column: last.Column}
+				parent.Content = append(parent.Content, last, value)
+			}
+			table = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: last.Line, Column: last.Column}
+			value.Content = append(value.Content, table)
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Sets the value of a key-value expression in the table, creating
+// the tables of dotted keys, (i.e. requests.cpu = "100m")
+func setTOMLValue(p *unstable.Parser, table *yaml.Node, expr *unstable.Node) error {
+	keys := tomlKeys(p, expr.Key())
+	parent, err := tomlTable(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, value := mappingEntry(parent, last.Value); value != nil {
+		return fmt.Errorf("line %d, column %d: key %s is already defined", last.Line, last.Column, last.Value)
+	}
+	value, err := tomlValue(p, expr.Value(), last)
+	if err != nil {
+		return err
+	}
+	parent.Content = append(parent.Content, last, value)
+	return nil
+}
+
+// Returns the key nodes of a possibly dotted key
+func tomlKeys(p *unstable.Parser, it unstable.Iterator) []*yaml.Node {
+	var keys []*yaml.Node
+	for it.Next() {
+		k := it.Node()
+		pos := p.Shape(k.Raw).Start
+		keys = append(keys, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(k.Data), Line: pos.Line, Column: pos.Column})
+	}
+	return keys
+}
+
+// Returns the table at the given keys of the parent table, creating
+// tables which don't exist. Keys of arrays of tables refer to the
+// last table of the array
+func tomlTable(parent *yaml.Node, keys []*yaml.Node) (*yaml.Node, error) {
+	for _, k := range keys {
+		_, value := mappingEntry(parent, k.Value)
+		if value == nil {
+			value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: k.Line, Column: k.Column}
+			parent.Content = append(parent.Content, k, value)
+		}
+		if value.Kind == yaml.SequenceNode && len(value.Content) > 0 {
+			value = value.Content[len(value.Content)-1]
+		}
+		if value.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d, column %d: %s is not a table", k.Line, k.Column, k.Value)
+		}
+		parent = value
+	}
+	return parent, nil
+}
+
+// Returns a TOML value as a YAML node, located at its key if
+// the value has no position of its own
+func tomlValue(p *unstable.Parser, v *unstable.Node, key *yaml.Node) (*yaml.Node, error) {
+	n := &yaml.Node{Kind: yaml.ScalarNode, Line: key.Line, Column: key.Column}
+	if v.Raw.Length > 0 {
+		pos := p.Shape(v.Raw).Start
+		n.Line, n.Column = pos.Line, pos.Column
+	}
+	switch v.Kind {
+	case unstable.String:
+		n.Tag, n.Value = "!!str", string(v.Data)
+	case unstable.Bool:
+		n.Tag, n.Value = "!!bool", string(v.Data)
+	case unstable.Integer:
+		i, err := strconv.ParseInt(string(v.Data), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d, column %d: invalid integer %s", n.Line, n.Column, v.Data)
+		}
+		n.Tag, n.Value = "!!int", strconv.FormatInt(i, 10)
+	case unstable.Float:
+		f, err := strconv.ParseFloat(strings.ReplaceAll(string(v.Data), "_", ""), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d, column %d: invalid float %s", n.Line, n.Column, v.Data)
+		}
+		n.Tag, n.Value = "!!float", strconv.FormatFloat(f, 'g', -1, 64)
+		switch {
+		case math.IsInf(f, 1):
+			n.Value = ".inf"
+		case math.IsInf(f, -1):
+			n.Value = "-.inf"
+		case math.IsNaN(f):
+			n.Value = ".nan"
+		}
+	case unstable.Array:
+		n.Kind, n.Tag = yaml.SequenceNode, "!!seq"
+		for it := v.Children(); it.Next(); {
+			item, err := tomlValue(p, it.Node(), n)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, item)
+		}
+	case unstable.InlineTable:
+		n.Kind, n.Tag = yaml.MappingNode, "!!map"
+		for it := v.Children(); it.Next(); {
+			if err := setTOMLValue(p, n, it.Node()); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		// Dates and times are kept as written
+		n.Tag, n.Value = "!!str", string(v.Data)
+	}
+	return n, nil
+}
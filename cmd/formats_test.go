@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestConvertTOML checks that TOML documents are converted into the
+// YAML mappings they describe, and that keys and tables defined
+// more than once are located
+func TestConvertTOML(t *testing.T) {
+	tests := []struct {
+		name     string
+		toml     string
+		expected string // YAML document, or the problem found
+	}{
+		{"values", "appName = \"svc\"\nport = 0x1F90\nratio = 1_000.5\ngit.init = true\n", "appName: svc\nport: 8080\nratio: 1000.5\ngit:\n    init: true\n"},
+		{"tables", "[container]\nprofile = \"alpine\"\n[container.environment]\nLOG_LEVEL = \"debug\"\n", "container:\n    profile: alpine\n    environment:\n        LOG_LEVEL: debug\n"},
+		{"inline tables", "kubernetes = { resources = { requests.cpu = \"100m\" } }\n", "kubernetes:\n    resources:\n        requests:\n            cpu: 100m\n"},
+		{"arrays", "ci = [\"github\", \"gitlab\"]\n", "ci:\n    - github\n    - gitlab\n"},
+		{"arrays of tables", "[[endpoints]]\npath = \"/v1/a\"\n[[endpoints]]\npath = \"/v1/b\"\nmethod = \"post\"\n", "endpoints:\n    - path: /v1/a\n    - path: /v1/b\n      method: post\n"},
+		{"tables of arrays of tables", "[[services]]\nname = \"a\"\n[services.container]\nprofile = \"alpine\"\n[[services]]\nname = \"b\"\n", "services:\n    - name: a\n      container:\n        profile: alpine\n    - name: b\n"},
+		{"table extended by dotted keys", "[container]\nenvironment.A = \"1\"\nenvironment.B = \"2\"\n", "container:\n    environment:\n        A: \"1\"\n        B: \"2\"\n"},
+		{"table declared after its subtable", "[a.b]\nc = 1\n[a]\nd = 2\n", "a:\n    b:\n        c: 1\n    d: 2\n"},
+		{"duplicate key", "port = 1\nport = 2\n", "line 2, column 1: key port is already defined"},
+		{"duplicate dotted key", "git.init = true\ngit.init = false\n", "line 2, column 5: key init is already defined"},
+		{"duplicate inline key", "git = { init = true, init = false }\n", "line 1, column 22: key init is already defined"},
+		{"duplicate table", "[git]\ninit = true\n[git]\nauthor = \"a\"\n", "line 3, column 2: table git is already defined"},
+		{"table over value", "git = 1\n[git.init]\n", "line 2, column 2: git is not a table"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := convertTOML([]byte(tt.toml))
+			if err != nil {
+				if err.Error() != tt.expected {
+					t.Errorf("Expected %q, got %q.", tt.expected, err)
+				}
+				return
+			}
+			out, err := yaml.Marshal(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, out)
+			}
+		})
+	}
+}
+
+// TestParseTOMLPositions checks that values converted from TOML are
+// located where they are written in the document
+func TestParseTOMLPositions(t *testing.T) {
+	content := "appName = \"svc\"\n\n[[endpoints]]\npath = \"/v1/a\"\n  method = \"fetch\"\n"
+	root, err := parseTOML([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newValidator(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}, nil)
+	expected := map[string]position{
+		"appName":             {"", 1, 1},
+		"endpoints":           {"", 3, 3},
+		"endpoints[0].path":   {"", 4, 1},
+		"endpoints[0].method": {"", 5, 3},
+	}
+	for path, pos := range expected {
+		if got := v.positions[path]; got != pos {
+			t.Errorf("Expected %s at %d:%d, got %d:%d.", path, pos.line, pos.column, got.line, got.column)
+		}
+	}
+	if _, err := parseTOML([]byte("port = 1\nport = 2\n")); err == nil || !strings.Contains(err.Error(), "line 2, column 1") {
+		t.Errorf("Expected duplicate key to be located, got %v.", err)
+	}
+}
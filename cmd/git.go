@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"os/exec"
+)
+
+var GITIGNORE_BASE = `# Compiled binaries
+bin/
+
+# Local environment and settings
+.env
+.env.*
+
+# Test coverage
+coverage.out
+*.coverprofile
+*.test
+
+# Editor and OS files
+.idea/
+.vscode/
+.DS_Store
+`
+
+var DOCKERIGNORE_BASE = `# Only go.mod, go.sum and cmd/api are needed to build the image
+.git
+.github
+.gitlab-ci.yml
+bin/
+remote/
+migrations/
+.env
+.env.*
+coverage.out
+*.md
+Makefile
+docker-compose.yaml
+`
+
+// Creates .gitignore and .dockerignore
+func GenerateIgnoreFiles(target string, data TemplateData) error {
+	// Create .gitignore
+	gitignore, err := createFile(".gitignore", target)
+	if err != nil {
+		return err
+	}
+	// Write .gitignore content to newly created file
+	if err := writeTemplate(gitignore, GITIGNORE_BASE, data); err != nil {
+		return err
+	}
+	// Create .dockerignore
+	dockerignore, err := createFile(".dockerignore", target)
+	if err != nil {
+		return err
+	}
+	// Write .dockerignore content to newly created file
+	if err := writeTemplate(dockerignore, DOCKERIGNORE_BASE, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Initializes a git repository in target and commits the generated project,
+// skipping if git isn't installed. The commit is skipped with a warning if no
+// author is given and git has no identity to commit with
+func initializeGitRepository(target, author string) error {
+	fmt.Printf("Initializing git repository in %s...\n", target)
+	if _, err := exec.LookPath("git"); err != nil {
+		fmt.Printf("--> Couldn't find git, skipping.\n")
+		return nil
+	}
+	// Use the given author for both author and committer so that the commit
+	// can be created on machines without a configured git identity
+	env := os.Environ()
+	if author != "" {
+		addr, err := mail.ParseAddress(author)
+		if err != nil {
+			return err
+		}
+		env = append(env,
+			"GIT_AUTHOR_NAME="+addr.Name, "GIT_AUTHOR_EMAIL="+addr.Address,
+			"GIT_COMMITTER_NAME="+addr.Name, "GIT_COMMITTER_EMAIL="+addr.Address,
+		)
+	}
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = target
+		cmd.Env = env
+		return cmd.CombinedOutput()
+	}
+	for _, args := range [][]string{{"init"}, {"add", "-A"}} {
+		if out, err := git(args...); err != nil {
+			fmt.Printf("--> Couldn't run git %s, aborting.\n%s", args[0], out)
+			return err
+		}
+	}
+	// Both identities are checked, as either can be missing
+	for _, ident := range []string{"GIT_AUTHOR_IDENT", "GIT_COMMITTER_IDENT"} {
+		if _, err := git("var", ident); err != nil {
+			fmt.Printf("--> Couldn't find a git identity, skipping the initial commit. Set git.author, or user.name and user.email with git config, to create it.\n")
+			return nil
+		}
+	}
+	if out, err := git("commit", "-m", "Initial commit generated by talbot"); err != nil {
+		fmt.Printf("--> Couldn't run git commit, aborting.\n%s", out)
+		return err
+	}
+	fmt.Printf("--> Successfully initialized git repository in %s, continuing\n", target)
+	return nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInitializeGitRepository checks that the generated project is
+// committed by the given author, and that the commit is skipped
+// rather than failing when git has no identity to commit with
+func TestInitializeGitRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("Skipping git repository tests without git.")
+	}
+	// Hide the git identity of the machine
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL", "EMAIL"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	// Identities can't be guessed from the user and host without this
+	if err := os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[user]\n\tuseConfigOnly = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		author   string
+		expected string // Author of the initial commit, if it's created
+	}{
+		{"author", "Jane Doe <jane@example.com>", "Jane Doe <jane@example.com>"},
+		{"no identity", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := t.TempDir()
+			if err := os.WriteFile(filepath.Join(target, "README.md"), []byte("# svc\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := initializeGitRepository(target, tt.author); err != nil {
+				t.Fatal(err)
+			}
+			cmd := exec.Command("git", "log", "--format=%an <%ae>")
+			cmd.Dir = target
+			out, err := cmd.Output()
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("Expected no commit, got one by %s.", out)
+				}
+				if _, err := os.Stat(filepath.Join(target, ".git")); err != nil {
+					t.Errorf("Expected repository to be initialized, got %q.", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(out)); got != tt.expected {
+				t.Errorf("Expected commit by %s, got %s.", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package cmd
+
+import "path/filepath"
+
+var HELM_CHART_BASE = `apiVersion: v2
+name: {{.AppName}}
+description: Helm chart for the {{.AppName}} server
+type: application
+version: 0.1.0
+appVersion: "dev"
+`
+
+var HELM_VALUES_BASE = `# Number of replicas when autoscaling is disabled
+replicaCount: {{.Kubernetes.Replicas}}
+
+image:
+  repository: {{.AppName}}
+  # Defaults to the appVersion of the chart
+  tag: ""
+  pullPolicy: IfNotPresent
+
+containerPort: {{.Port}}
+
+service:
+  type: ClusterIP
+  port: 80
+
+# Settings passed to the server through a ConfigMap
+env:
+{{- range .PlainConfigFields}}
+  {{.GenerateManifestEntry}}
+{{- else}} {}
+{{- end}}
+
+# Settings passed to the server through a Secret, set these
+# at install time and never commit their values
+secretEnv:
+{{- range .SecretConfigFields}}
+  {{.GenerateManifestEntry}}
+{{- else}} {}
+{{- end}}
+
+ingress:
+  enabled: {{if .Kubernetes.Ingress.Host}}true{{else}}false{{end}}
+  className: "{{.Kubernetes.Ingress.ClassName}}"
+  annotations: {}
+  host: "{{.Kubernetes.Ingress.Host}}"
+  # Secret containing the TLS certificate for host, TLS is disabled if empty
+  tlsSecret: "{{.Kubernetes.Ingress.TLSSecret}}"
+
+probes:
+  liveness:
+    path: /v1/healthcheck
+    initialDelaySeconds: 5
+    periodSeconds: 10
+  readiness:
+    path: /v1/readycheck
+    periodSeconds: 5
+    failureThreshold: 2
+
+resources:
+  requests:
+    cpu: {{.Kubernetes.Requests.CPU}}
+    memory: {{.Kubernetes.Requests.Memory}}
+  limits:
+    cpu: {{.Kubernetes.Limits.CPU}}
+    memory: {{.Kubernetes.Limits.Memory}}
+
+autoscaling:
+  enabled: true
+  minReplicas: {{.Kubernetes.MinReplicas}}
+  maxReplicas: {{.Kubernetes.MaxReplicas}}
+  targetCPUUtilizationPercentage: {{.Kubernetes.TargetCPUUtilization}}
+
+podDisruptionBudget:
+  maxUnavailable: 1
+`
+
+// The remaining chart files are Helm templates, which are written
+// as-is rather than being rendered with the template data
+
+var HELM_HELPERS_BASE = `{{/* Name of the release resources */}}
+{{- define "chart.fullname" -}}
+{{- if contains .Chart.Name .Release.Name }}
+{{- .Release.Name | trunc 63 | trimSuffix "-" }}
+{{- else }}
+{{- printf "%s-%s" .Release.Name .Chart.Name | trunc 63 | trimSuffix "-" }}
+{{- end }}
+{{- end }}
+
+{{/* Labels used to select the pods of the release */}}
+{{- define "chart.selectorLabels" -}}
+app.kubernetes.io/name: {{ .Chart.Name }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+{{- end }}
+
+{{/* Labels added to every resource of the release */}}
+{{- define "chart.labels" -}}
+{{ include "chart.selectorLabels" . }}
+app.kubernetes.io/version: {{ .Values.image.tag | default .Chart.AppVersion | quote }}
+app.kubernetes.io/managed-by: {{ .Release.Service }}
+helm.sh/chart: {{ printf "%s-%s" .Chart.Name .Chart.Version }}
+{{- end }}
+`
+
+var HELM_DEPLOYMENT_BASE = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "chart.fullname" . }}
+  labels:
+    {{- include "chart.labels" . | nindent 4 }}
+spec:
+  {{- if not .Values.autoscaling.enabled }}
+  replicas: {{ .Values.replicaCount }}
+  {{- end }}
+  selector:
+    matchLabels:
+      {{- include "chart.selectorLabels" . | nindent 6 }}
+  template:
+    metadata:
+      annotations:
+        # Restart pods when their settings change
+        checksum/config: {{ include (print $.Template.BasePath "/configmap.yaml") . | sha256sum }}
+        checksum/secret: {{ include (print $.Template.BasePath "/secret.yaml") . | sha256sum }}
+      labels:
+        {{- include "chart.selectorLabels" . | nindent 8 }}
+    spec:
+      # Leave time for the server to fail readiness and drain in-flight requests
+      terminationGracePeriodSeconds: 30
+      securityContext:
+        runAsNonRoot: true
+        runAsUser: 65532
+        runAsGroup: 65532
+        seccompProfile:
+          type: RuntimeDefault
+      containers:
+        - name: {{ .Chart.Name }}
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag | default .Chart.AppVersion }}"
+          imagePullPolicy: {{ .Values.image.pullPolicy }}
+          ports:
+            - name: http
+              containerPort: {{ .Values.containerPort }}
+          envFrom:
+            - configMapRef:
+                name: {{ include "chart.fullname" . }}-config
+            - secretRef:
+                name: {{ include "chart.fullname" . }}-secret
+          livenessProbe:
+            httpGet:
+              path: {{ .Values.probes.liveness.path }}
+              port: http
+            initialDelaySeconds: {{ .Values.probes.liveness.initialDelaySeconds }}
+            periodSeconds: {{ .Values.probes.liveness.periodSeconds }}
+          readinessProbe:
+            httpGet:
+              path: {{ .Values.probes.readiness.path }}
+              port: http
+            periodSeconds: {{ .Values.probes.readiness.periodSeconds }}
+            failureThreshold: {{ .Values.probes.readiness.failureThreshold }}
+          resources:
+            {{- toYaml .Values.resources | nindent 12 }}
+          securityContext:
+            allowPrivilegeEscalation: false
+            readOnlyRootFilesystem: true
+            capabilities:
+              drop:
+                - ALL
+          volumeMounts:
+            - name: tmp
+              mountPath: /tmp
+      volumes:
+        - name: tmp
+          emptyDir: {}
+`
+
+var HELM_SERVICE_BASE = `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ include "chart.fullname" . }}
+  labels:
+    {{- include "chart.labels" . | nindent 4 }}
+spec:
+  type: {{ .Values.service.type }}
+  selector:
+    {{- include "chart.selectorLabels" . | nindent 4 }}
+  ports:
+    - name: http
+      port: {{ .Values.service.port }}
+      targetPort: http
+`
+
+var HELM_CONFIGMAP_BASE = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ include "chart.fullname" . }}-config
+  labels:
+    {{- include "chart.labels" . | nindent 4 }}
+data:
+  {{- range $name, $value := .Values.env }}
+  {{ $name }}: {{ $value | toString | quote }}
+  {{- end }}
+`
+
+var HELM_SECRET_BASE = `apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ include "chart.fullname" . }}-secret
+  labels:
+    {{- include "chart.labels" . | nindent 4 }}
+type: Opaque
+stringData:
+  {{- range $name, $value := .Values.secretEnv }}
+  {{ $name }}: {{ $value | toString | quote }}
+  {{- end }}
+`
+
+var HELM_INGRESS_BASE = `{{- if .Values.ingress.enabled -}}
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ include "chart.fullname" . }}
+  labels:
+    {{- include "chart.labels" . | nindent 4 }}
+  {{- with .Values.ingress.annotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  {{- with .Values.ingress.className }}
+  ingressClassName: {{ . }}
+  {{- end }}
+  {{- if .Values.ingress.tlsSecret }}
+  tls:
+    - hosts:
+        - {{ .Values.ingress.host | quote }}
+      secretName: {{ .Values.ingress.tlsSecret }}
+  {{- end }}
+  rules:
+    - host: {{ .Values.ingress.host | quote }}
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: {{ include "chart.fullname" . }}
+                port:
+                  name: http
+{{- end }}
+`
+
+var HELM_HPA_BASE = `{{- if .Values.autoscaling.enabled -}}
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{ include "chart.fullname" . }}
+  labels:
+    {{- include "chart.labels" . | nindent 4 }}
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{ include "chart.fullname" . }}
+  minReplicas: {{ .Values.autoscaling.minReplicas }}
+  maxReplicas: {{ .Values.autoscaling.maxReplicas }}
+  metrics:
+    - type: Resource
+      resource:
+        name: cpu
+        target:
+          type: Utilization
+          averageUtilization: {{ .Values.autoscaling.targetCPUUtilizationPercentage }}
+{{- end }}
+`
+
+var HELM_PDB_BASE = `apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: {{ include "chart.fullname" . }}
+  labels:
+    {{- include "chart.labels" . | nindent 4 }}
+spec:
+  maxUnavailable: {{ .Values.podDisruptionBudget.maxUnavailable }}
+  selector:
+    matchLabels:
+      {{- include "chart.selectorLabels" . | nindent 6 }}
+`
+
+// Creates a Helm chart with values derived from the configuration
+func GenerateHelmChart(target string, data TemplateData) error {
+	chart := filepath.Join(target, "remote", "chart")
+	if err := createDirectories(chart, "templates"); err != nil {
+		return err
+	}
+	// Chart metadata and values are rendered with the template data
+	for _, m := range [][]string{
+		{"Chart.yaml", HELM_CHART_BASE},
+		{"values.yaml", HELM_VALUES_BASE},
+	} {
+		f, err := createFile(m[0], chart)
+		if err != nil {
+			return err
+		}
+		if err := writeTemplate(f, m[1], data); err != nil {
+			return err
+		}
+	}
+	// Chart templates are rendered by Helm at install time
+	for _, m := range [][]string{
+		{"templates/_helpers.tpl", HELM_HELPERS_BASE},
+		{"templates/deployment.yaml", HELM_DEPLOYMENT_BASE},
+		{"templates/service.yaml", HELM_SERVICE_BASE},
+		{"templates/configmap.yaml", HELM_CONFIGMAP_BASE},
+		{"templates/secret.yaml", HELM_SECRET_BASE},
+		{"templates/ingress.yaml", HELM_INGRESS_BASE},
+		{"templates/hpa.yaml", HELM_HPA_BASE},
+		{"templates/pdb.yaml", HELM_PDB_BASE},
+	} {
+		f, err := createFile(m[0], chart)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(f, m[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
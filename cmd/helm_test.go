@@ -0,0 +1,353 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Matches references to chart values in Helm templates
+var valuesRefRegex = regexp.MustCompile(`\.Values((?:\.[A-Za-z0-9_]+)+)`)
+
+// readYaml is a helper function that unmarshals a generated YAML file
+func readYaml(t *testing.T, path string) map[string]any {
+	t.Helper() // Mark the function as test helper
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]any{}
+	if err := yaml.Unmarshal(content, &out); err != nil {
+		t.Fatalf("couldn't parse %s: %q", path, err)
+	}
+	return out
+}
+
+// lookup returns the value at a dot separated path of nested maps
+func lookup(m map[string]any, path string) (any, bool) {
+	var v any = m
+	for _, key := range strings.Split(strings.Trim(path, "."), ".") {
+		next, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = next[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// TestGenerateHelmChart renders a chart and checks that its values are
+// derived from the configuration and cover every value used by its templates
+func TestGenerateHelmChart(t *testing.T) {
+	conf := YamlConfig{
+		AppName: "billing",
+		ModName: "example.com/billing",
+		Port:    8080,
+		ConfigFields: []ConfigFieldDefinition{
+			{Name: "dbDsn", Type: "string", Secret: true, Required: true},
+			{Name: "maxWorkers", Type: "int", Default: "8"},
+		},
+		Kubernetes: KubernetesDefinition{
+			Replicas: 3,
+			Ingress:  IngressDefinition{Host: "billing.example.com"},
+		}.withDefaults(),
+	}
+	target := t.TempDir()
+	if err := GenerateHelmChart(target, NewTemplateData(&conf)); err != nil {
+		t.Fatal(err)
+	}
+	chart := filepath.Join(target, "remote", "chart")
+
+	meta := readYaml(t, filepath.Join(chart, "Chart.yaml"))
+	if meta["apiVersion"] != "v2" || meta["name"] != "billing" {
+		t.Errorf("Expected v2 chart named billing, got %v.", meta)
+	}
+
+	values := readYaml(t, filepath.Join(chart, "values.yaml"))
+	expected := map[string]any{
+		"replicaCount":                       int64(3),
+		"containerPort":                      int64(8080),
+		"image.repository":                   "billing",
+		"env.PORT":                           "8080",
+		"env.MAX_WORKERS":                    "8",
+		"secretEnv.DB_DSN":                   "",
+		"ingress.enabled":                    true,
+		"ingress.host":                       "billing.example.com",
+		"probes.liveness.path":               "/v1/healthcheck",
+		"probes.readiness.path":              "/v1/readycheck",
+		"resources.limits.memory":            "128Mi",
+		"autoscaling.minReplicas":            int64(3),
+		"resources.requests.cpu":             "100m",
+		"podDisruptionBudget.maxUnavailable": int64(1),
+	}
+	for path, exp := range expected {
+		got, ok := lookup(values, path)
+		if !ok {
+			t.Errorf("Expected value %s to be set.", path)
+			continue
+		}
+		if got != exp {
+			t.Errorf("Expected %s to be %v, got %v.", path, exp, got)
+		}
+	}
+	if _, ok := lookup(values, "env.DB_DSN"); ok {
+		t.Error("Expected secret setting DB_DSN to be excluded from env.")
+	}
+
+	templates, err := filepath.Glob(filepath.Join(chart, "templates", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) == 0 {
+		t.Fatal("Expected chart templates, got none.")
+	}
+	for _, tmpl := range templates {
+		content, err := os.ReadFile(tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ref := range valuesRefRegex.FindAllStringSubmatch(string(content), -1) {
+			if _, ok := lookup(values, ref[1]); !ok {
+				t.Errorf("%s references .Values%s which is missing from values.yaml", filepath.Base(tmpl), ref[1])
+			}
+		}
+	}
+}
+
+// helmFuncs returns the functions of Helm used by the chart templates,
+// with include executing the named template of the given set
+func helmFuncs(set **template.Template) template.FuncMap {
+	return template.FuncMap{
+		"include": func(name string, data any) (string, error) {
+			var buf bytes.Buffer
+			err := (*set).ExecuteTemplate(&buf, name, data)
+			return buf.String(), err
+		},
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"trunc": func(n int, s string) string {
+			if len(s) > n {
+				return s[:n]
+			}
+			return s
+		},
+		"default": func(def, value any) any {
+			if value == nil || value == "" {
+				return def
+			}
+			return value
+		},
+		"toString": func(v any) string { return fmt.Sprint(v) },
+		"quote":    func(v any) string { return strconv.Quote(fmt.Sprint(v)) },
+		"nindent": func(n int, s string) string {
+			pad := strings.Repeat(" ", n)
+			return "\n" + pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+		},
+		"toYaml": func(v any) (string, error) {
+			out, err := sigsyaml.Marshal(v)
+			return strings.TrimSuffix(string(out), "\n"), err
+		},
+		"sha256sum": func(s string) string { return fmt.Sprintf("%x", sha256.Sum256([]byte(s))) },
+	}
+}
+
+// renderChart is a helper function that renders the templates of a
+// generated chart with its values, as helm template would for a release
+// named after the chart, returning the resource of each template by name
+func renderChart(t *testing.T, chart string) map[string]map[string]any {
+	t.Helper() // Mark the function as test helper
+	meta := readYaml(t, filepath.Join(chart, "Chart.yaml"))
+	name := meta["name"].(string)
+	data := map[string]any{
+		"Values":   readYaml(t, filepath.Join(chart, "values.yaml")),
+		"Chart":    map[string]any{"Name": name, "Version": meta["version"], "AppVersion": meta["appVersion"]},
+		"Release":  map[string]any{"Name": name, "Service": "Helm"},
+		"Template": map[string]any{"BasePath": name + "/templates"},
+	}
+	var set *template.Template
+	set = template.New(name).Funcs(helmFuncs(&set)).Option("missingkey=error")
+	files, err := filepath.Glob(filepath.Join(chart, "templates", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := set.New(name + "/templates/" + filepath.Base(file)).Parse(string(content)); err != nil {
+			t.Fatalf("couldn't parse %s: %q", file, err)
+		}
+	}
+	resources := map[string]map[string]any{}
+	for _, file := range files {
+		base := filepath.Base(file)
+		if strings.HasPrefix(base, "_") {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := set.ExecuteTemplate(&buf, name+"/templates/"+base, data); err != nil {
+			t.Fatalf("couldn't render %s: %q", base, err)
+		}
+		// Templates of disabled resources render nothing
+		if strings.TrimSpace(buf.String()) == "" {
+			continue
+		}
+		resource := map[string]any{}
+		if err := yaml.Unmarshal(buf.Bytes(), &resource); err != nil {
+			t.Fatalf("couldn't parse rendered %s: %q\n%s", base, err, buf.String())
+		}
+		resources[base] = resource
+	}
+	return resources
+}
+
+// field returns the value at the given keys of nested maps
+// and indexes of nested lists
+func field(v any, path ...any) (any, bool) {
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[key]; !ok {
+				return nil, false
+			}
+		case int:
+			list, ok := v.([]any)
+			if !ok || key >= len(list) {
+				return nil, false
+			}
+			v = list[key]
+		}
+	}
+	return v, true
+}
+
+// TestRenderHelmChart renders the templates of generated charts with
+// their values, as Helm would, and checks the resources they describe
+func TestRenderHelmChart(t *testing.T) {
+	type check struct {
+		resource string // Template rendering the resource
+		path     []any
+		value    any // Expected value, or nil if unset
+	}
+	at := func(prefix []any, keys ...any) []any {
+		return append(append([]any{}, prefix...), keys...)
+	}
+	container := []any{"spec", "template", "spec", "containers", 0}
+	backend := []any{"spec", "rules", 0, "http", "paths", 0, "backend", "service"}
+	common := []check{
+		{"deployment.yaml", []any{"kind"}, "Deployment"},
+		{"deployment.yaml", []any{"metadata", "name"}, "billing"},
+		{"deployment.yaml", []any{"spec", "replicas"}, nil}, // Scaled by the autoscaler
+		{"deployment.yaml", []any{"spec", "selector", "matchLabels", "app.kubernetes.io/name"}, "billing"},
+		{"deployment.yaml", at(container, "image"), "billing:dev"},
+		{"deployment.yaml", at(container, "ports", 0, "containerPort"), int64(8080)},
+		{"deployment.yaml", at(container, "livenessProbe", "httpGet", "path"), "/v1/healthcheck"},
+		{"deployment.yaml", at(container, "readinessProbe", "httpGet", "path"), "/v1/readycheck"},
+		{"deployment.yaml", at(container, "resources", "requests", "cpu"), "100m"},
+		{"deployment.yaml", at(container, "resources", "limits", "memory"), "128Mi"},
+		{"deployment.yaml", at(container, "envFrom", 0, "configMapRef", "name"), "billing-config"},
+		{"deployment.yaml", at(container, "envFrom", 1, "secretRef", "name"), "billing-secret"},
+		{"service.yaml", []any{"kind"}, "Service"},
+		{"service.yaml", []any{"spec", "ports", 0, "port"}, int64(80)},
+		{"service.yaml", []any{"spec", "ports", 0, "targetPort"}, "http"},
+		{"configmap.yaml", []any{"kind"}, "ConfigMap"},
+		{"configmap.yaml", []any{"data", "PORT"}, "8080"},
+		{"configmap.yaml", []any{"data", "MAX_WORKERS"}, "8"},
+		{"configmap.yaml", []any{"data", "DB_DSN"}, nil}, // Secret settings are left out
+		{"secret.yaml", []any{"kind"}, "Secret"},
+		{"secret.yaml", []any{"stringData", "DB_DSN"}, ""},
+		{"hpa.yaml", []any{"kind"}, "HorizontalPodAutoscaler"},
+		{"hpa.yaml", []any{"spec", "scaleTargetRef", "name"}, "billing"},
+		{"hpa.yaml", []any{"spec", "minReplicas"}, int64(3)},
+		{"hpa.yaml", []any{"spec", "maxReplicas"}, int64(5)},
+		{"pdb.yaml", []any{"kind"}, "PodDisruptionBudget"},
+		{"pdb.yaml", []any{"spec", "maxUnavailable"}, int64(1)},
+	}
+	tests := []struct {
+		name      string
+		ingress   IngressDefinition
+		resources []string // Templates rendering a resource
+		checks    []check
+	}{
+		{
+			name:      "ingress",
+			ingress:   IngressDefinition{Host: "billing.example.com", ClassName: "nginx", TLSSecret: "billing-tls"},
+			resources: []string{"configmap.yaml", "deployment.yaml", "hpa.yaml", "ingress.yaml", "pdb.yaml", "secret.yaml", "service.yaml"},
+			checks: []check{
+				{"ingress.yaml", []any{"kind"}, "Ingress"},
+				{"ingress.yaml", []any{"spec", "ingressClassName"}, "nginx"},
+				{"ingress.yaml", []any{"spec", "rules", 0, "host"}, "billing.example.com"},
+				{"ingress.yaml", []any{"spec", "tls", 0, "hosts", 0}, "billing.example.com"},
+				{"ingress.yaml", []any{"spec", "tls", 0, "secretName"}, "billing-tls"},
+				{"ingress.yaml", at(backend, "name"), "billing"},
+				{"ingress.yaml", at(backend, "port", "name"), "http"},
+			},
+		},
+		{
+			name:      "no ingress",
+			resources: []string{"configmap.yaml", "deployment.yaml", "hpa.yaml", "pdb.yaml", "secret.yaml", "service.yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := YamlConfig{
+				AppName: "billing",
+				ModName: "example.com/billing",
+				Port:    8080,
+				ConfigFields: []ConfigFieldDefinition{
+					{Name: "dbDsn", Type: "string", Secret: true, Required: true},
+					{Name: "maxWorkers", Type: "int", Default: "8"},
+				},
+				Kubernetes: KubernetesDefinition{Replicas: 3, MaxReplicas: 5, Ingress: tt.ingress}.withDefaults(),
+			}
+			target := t.TempDir()
+			if err := GenerateHelmChart(target, NewTemplateData(&conf)); err != nil {
+				t.Fatal(err)
+			}
+			resources := renderChart(t, filepath.Join(target, "remote", "chart"))
+			var rendered []string
+			for name := range resources {
+				rendered = append(rendered, name)
+			}
+			sort.Strings(rendered)
+			if !reflect.DeepEqual(rendered, tt.resources) {
+				t.Errorf("Expected resources from %v, got %v.", tt.resources, rendered)
+			}
+			for _, c := range append(common, tt.checks...) {
+				got, ok := field(resources[c.resource], c.path...)
+				if c.value == nil {
+					if ok {
+						t.Errorf("Expected %v of %s to be unset, got %v.", c.path, c.resource, got)
+					}
+				} else if !ok || got != c.value {
+					t.Errorf("Expected %v of %s to be %v, got %v.", c.path, c.resource, c.value, got)
+				}
+			}
+			// The service routes requests to the pods of the deployment
+			labels, _ := field(resources["deployment.yaml"], "spec", "template", "metadata", "labels")
+			selector, _ := field(resources["service.yaml"], "spec", "selector")
+			if !reflect.DeepEqual(labels, selector) {
+				t.Errorf("Expected service to select pods labeled %v, got %v.", labels, selector)
+			}
+		})
+	}
+}
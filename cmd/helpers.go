@@ -9,6 +9,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
 )
 
 // Checks to see if the given directory exists
@@ -37,21 +41,106 @@ func createTargetDirectory(target string) error {
 	return nil
 }
 
-// Initializes go module
-func initializeGoMod(modName, target string) error {
+// Initializes go module by writing go.mod, with the go directive
+// and toolchain line of the given module settings
+func initializeGoMod(modName, target string, modules ModuleDefinition) error {
 	fmt.Printf("Creating go module named %s in %s...\n", modName, target)
-	cmd := exec.Command("go", "mod", "init", modName)
-	cmd.Dir = target
-	_, err := cmd.Output()
+	content, err := formatGoMod(modName, modules)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(target, "go.mod"), content, 0644)
+	}
 	if err != nil {
 		fmt.Printf("--> Couldn't create go module %s, aborting.\n", modName)
 		return err
-	} else {
-		fmt.Printf("--> Successfully created go module %s, continuing\n", modName)
+	}
+	fmt.Printf("--> Successfully created go module %s, continuing\n", modName)
+	return nil
+}
+
+// Returns the content of a go.mod declaring the module
+func formatGoMod(modName string, modules ModuleDefinition) ([]byte, error) {
+	f := new(modfile.File)
+	if err := f.AddModuleStmt(modName); err != nil {
+		return nil, err
+	}
+	if err := f.AddGoStmt(modules.Go); err != nil {
+		return nil, err
+	}
+	if modules.Toolchain != "" {
+		if err := f.AddToolchainStmt(modules.Toolchain); err != nil {
+			return nil, err
+		}
+	}
+	return f.Format()
+}
+
+// Creates nested subdirectories of target, including any missing parents
+func createDirectories(target string, dirs ...string) error {
+	for _, d := range dirs {
+		t := filepath.Join(target, d)
+		fmt.Printf("Creating subdirectory %s...\n", t)
+		if err := os.MkdirAll(t, 0755); err != nil {
+			fmt.Printf("--> Couldn't create directory %s, aborting.\n", t)
+			return err
+		}
+		fmt.Printf("--> Successfully created directory %s, continuing\n", t)
 	}
 	return nil
 }
 
+// Reads the go directive from the go module in target
+func readGoVersion(target string) (string, error) {
+	f, err := readGoMod(target)
+	if err != nil {
+		return "", err
+	}
+	if f.Go == nil {
+		return "", fmt.Errorf("no go directive found in %s", filepath.Join(target, "go.mod"))
+	}
+	return f.Go.Version, nil
+}
+
+// Parses the go module in target
+func readGoMod(target string) (*modfile.File, error) {
+	file := filepath.Join(target, "go.mod")
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return modfile.Parse(file, content, nil)
+}
+
+// Returns whether a go version is at least the minimum version,
+// comparing major and minor versions, (i.e. 1.22.3 is at least 1.22)
+func goVersionAtLeast(version, minimum string) bool {
+	major, minor, ok := parseGoVersion(version)
+	if !ok {
+		return false
+	}
+	minMajor, minMinor, ok := parseGoVersion(minimum)
+	if !ok {
+		return false
+	}
+	return major > minMajor || (major == minMajor && minor >= minMinor)
+}
+
+// Returns the major and minor versions of a go version, (i.e. 1.22rc1 -> 1, 22)
+func parseGoVersion(version string) (int, int, bool) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
 // Scaffolds the project file structure and templates README documentation
 func ScaffoldProject(target string, folders [][]string, readme *os.File) error {
 	if err := writeFile(readme, "## File Structure\n\n"); err != nil {
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// runInit is a helper function that runs talbot init with the given
+// arguments, returning its output. Flags are reset afterwards, as the
+// command is shared by every test
+func runInit(t *testing.T, args ...string) (string, error) {
+	t.Helper() // Mark the function as test helper
+	defer initCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Value.Set(f.DefValue)
+		f.Changed = false
+	})
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs(append([]string{"init"}, args...))
+	defer rootCmd.SetArgs(nil)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// TestInitDefaults checks that init --defaults writes a valid configuration
+// with the default answers, without asking any questions
+func TestInitDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool // Whether the configuration file already exists
+		args     []string
+		fails    string // Problem reported, if any
+	}{
+		{"defaults", false, []string{"-n", "payments-api"}, ""},
+		{"existing file", true, []string{"-n", "payments-api"}, "already exists, use --force to overwrite it"},
+		{"overwritten file", true, []string{"-n", "payments-api", "--force"}, ""},
+		{"invalid app name", false, []string{"-n", "Payments_API"}, `app name "Payments_API" must be at most 63 lowercase letters`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "talbot.yaml")
+			if tt.existing {
+				if err := os.WriteFile(filename, []byte("appName: existing\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			out, err := runInit(t, append([]string{"--defaults", "-o", filename}, tt.args...)...)
+			if tt.fails != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.fails) {
+					t.Fatalf("Expected problem %q, got %v.", tt.fails, err)
+				}
+				if content, err := os.ReadFile(filename); tt.existing && (err != nil || string(content) != "appName: existing\n") {
+					t.Errorf("Expected %s to be left as it was, got %q.", filename, content)
+				} else if !tt.existing && err == nil {
+					t.Errorf("Expected %s not to be written.", filename)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := "Generate the server with: talbot make -c " + filename; !strings.Contains(out, expected) {
+				t.Errorf("Expected output to contain %q, got %q.", expected, out)
+			}
+			if strings.Contains(out, "?") {
+				t.Errorf("Expected no questions to be asked, got %q.", out)
+			}
+			conf, err := loadConfig(filename, nil)
+			if err != nil {
+				t.Fatalf("Expected written configuration to be valid, got %q.", err)
+			}
+			defaults := defaultInitAnswers("payments-api")
+			if conf.AppName != defaults.AppName || conf.ModName != defaults.ModName || conf.Router != defaults.Router || conf.Deploy != defaults.Deploy {
+				t.Errorf("Expected default answers %+v, got %+v.", defaults, conf)
+			}
+			if len(conf.Endpoints) != 0 || len(conf.ConfigFields) != 0 || len(conf.Container.Services) != 0 {
+				t.Errorf("Expected no endpoints or database, got %+v.", conf)
+			}
+		})
+	}
+}
+
+// TestWriteInitConfig checks that the configuration written from
+// any answers, including values which need quoting, is valid
+func TestWriteInitConfig(t *testing.T) {
+	for _, database := range databaseNames() {
+		t.Run(database, func(t *testing.T) {
+			answers := defaultInitAnswers("payments-api")
+			answers.ModName = "example.com/payments-api"
+			answers.Router = "chi"
+			answers.Database = database
+			answers.Endpoints = []EndpointDefinition{
+				{Path: "/v1/payments/{id}", Method: "GET", Description: "Returns a payment in ${CURRENCY}: by id, # not a comment, $$"},
+				{Path: "/v1/payments", Method: "POST"},
+			}
+			filename := filepath.Join(t.TempDir(), "talbot.yaml")
+			if err := writeInitConfig(filename, answers); err != nil {
+				t.Fatal(err)
+			}
+			conf, err := loadConfig(filename, nil)
+			if err != nil {
+				t.Fatalf("Expected written configuration to be valid, got %q.", err)
+			}
+			if conf.Endpoints[0].Description != answers.Endpoints[0].Description {
+				t.Errorf("Expected description %q, got %q.", answers.Endpoints[0].Description, conf.Endpoints[0].Description)
+			}
+			if preset, ok := databasePresets[database]; ok {
+				if len(conf.Container.Services) != 1 || conf.Container.Services[0].Name != preset.Name {
+					t.Errorf("Expected %s service, got %+v.", preset.Name, conf.Container.Services)
+				}
+				if len(conf.ConfigFields) != 1 || conf.ConfigFields[0].Name != preset.Setting {
+					t.Errorf("Expected %s setting, got %+v.", preset.Setting, conf.ConfigFields)
+				}
+			}
+		})
+	}
+}
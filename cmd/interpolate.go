@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Placeholders of environment variables in configuration values, (i.e.
+// ${ORG_MODULE_PREFIX} or ${REPLICAS:-2}), and $$ escaping a dollar sign
+var placeholderRegex = regexp.MustCompile(`\$\$|\$\{([^}]*)\}`)
+
+// Contents of a placeholder, a variable name with an optional default
+var placeholderContentRegex = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(:-(.*))?$`)
+
+// Returns the value with placeholders replaced by the environment variables
+// they name, looked up with lookupEnv. Variables which are unset or empty
+// are replaced by the default of the placeholder, and variables without
+// a default must be set
+func interpolate(value string, lookupEnv func(string) (string, bool)) (string, error) {
+	if rest := placeholderRegex.ReplaceAllString(value, ""); strings.Contains(rest, "${") {
+		return "", fmt.Errorf("unterminated placeholder in %q, placeholders are written ${VAR} or ${VAR:-default}", value)
+	}
+	var err error
+	result := placeholderRegex.ReplaceAllStringFunc(value, func(placeholder string) string {
+		if placeholder == "$$" {
+			return "$"
+		}
+		m := placeholderContentRegex.FindStringSubmatch(placeholder[2 : len(placeholder)-1])
+		if m == nil {
+			if err == nil {
+				err = fmt.Errorf("invalid placeholder %s, placeholders are written ${VAR} or ${VAR:-default}", placeholder)
+			}
+			return placeholder
+		}
+		name, hasDefault, def := m[1], m[2] != "", m[3]
+		if env, ok := lookupEnv(name); ok && (env != "" || !hasDefault) {
+			return env
+		}
+		if hasDefault {
+			return def
+		}
+		if err == nil {
+			err = fmt.Errorf("environment variable %s is not set, set it or give a default with ${%s:-default}", name, name)
+		}
+		return placeholder
+	})
+	return result, err
+}
+
+// Escapes the dollar signs of values written to a configuration file,
+// (i.e. ${PRICE} -> $${PRICE}), so that they are read back as written
+func escapeDollars(value string) string {
+	return strings.ReplaceAll(value, "$", "$$")
+}
+
+// Escapes the dollar signs of the string values of the node and its
+// children. Keys of mappings aren't interpolated, so they are kept as written
+func escapeDollarSigns(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!str" {
+			n.Value = escapeDollars(n.Value)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			escapeDollarSigns(n.Content[i])
+		}
+	default:
+		for _, c := range n.Content {
+			escapeDollarSigns(c)
+		}
+	}
+}
+
+// Replaces placeholders in the string value at path with the environment
+// variables they name. Values of settings which aren't strings take the
+// type of the value they resolve to, (i.e. port: ${PORT:-4000})
+func (v *validator) interpolate(path string, n *yaml.Node, s *jsonSchema) {
+	value, err := interpolate(n.Value, os.LookupEnv)
+	if err != nil {
+		v.report(path, err.Error())
+		*n = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: n.Line, Column: n.Column}
+		return
+	}
+	original := n.Value
+	n.Value = value
+	if s.Type.allows("string") {
+		return
+	}
+	// Resolve the type as if the value was written unquoted
+	n.Tag, n.Style = "", 0
+	if typ := nodeType(n); typ != "null" && !s.Type.allows(typ) {
+		v.report(path, fmt.Sprintf("%s resolved to %q, %s", original, value, typeMismatch(n, typ, s.Type)))
+		*n = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: n.Line, Column: n.Column}
+	}
+}
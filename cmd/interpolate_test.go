@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestInterpolate checks that placeholders are replaced by the
+// environment variables they name, or their defaults
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"ORG": "example.com", "EMPTY": "", "PORT": "8080"}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	tests := []struct {
+		value    string
+		expected string
+		fails    bool
+	}{
+		{"plain", "plain", false},
+		{"${ORG}/billing", "example.com/billing", false},
+		{"${ORG}/${ORG}", "example.com/example.com", false},
+		{"$$", "$", false},
+		{"$${ORG}", "${ORG}", false},
+		{"price: $5", "price: $5", false},
+		{"$$$${ORG}", "$${ORG}", false},
+		{"${PORT:-4000}", "8080", false},
+		{"${UNSET:-4000}", "4000", false},
+		{"${EMPTY:-4000}", "4000", false},
+		{"${UNSET:-}", "", false},
+		{"${UNSET:-a:-b}", "a:-b", false},
+		{"${EMPTY}", "", false},
+		{"${UNSET}", "", true},
+		{"${ORG", "", true},
+		{"${}", "", true},
+		{"${1ORG}", "", true},
+		{"${ORG:4000}", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := interpolate(tt.value, lookupEnv)
+			if tt.fails {
+				if err == nil {
+					t.Errorf("Expected %q to fail, got %q.", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected %q to be interpolated, got %q.", tt.value, err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q.", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestInterpolateTypes checks that interpolated values of settings which
+// aren't strings take the type of the value they resolve to
+func TestInterpolateTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		env      map[string]string
+		field    func(*YamlConfig) any // Returns the setting interpolated
+		value    any
+		problems []string
+	}{
+		{"default integer", "appName: svc\nport: ${PORT:-4000}\n", nil, func(c *YamlConfig) any { return c.Port }, 4000, nil},
+		{"integer", "appName: svc\nport: ${PORT:-4000}\n", map[string]string{"PORT": "8080"}, func(c *YamlConfig) any { return c.Port }, 8080, nil},
+		{"boolean", "appName: svc\nmodules:\n  offline: ${OFFLINE}\n", map[string]string{"OFFLINE": "true"}, func(c *YamlConfig) any { return c.Modules.Offline }, true, nil},
+		{"number kept as string", "appName: svc\ncontainer:\n  environment:\n    WORKERS: ${WORKERS}\n", map[string]string{"WORKERS": "42"}, func(c *YamlConfig) any { return c.Container.Environment["WORKERS"] }, "42", nil},
+		{"quoted", "appName: \"${NAME}\"\n", map[string]string{"NAME": "billing"}, func(c *YamlConfig) any { return c.AppName }, "billing", nil},
+		{"aliases", "appName: svc\ncontainer:\n  environment:\n    A: &x \"cost $${X}\"\n    B: *x\n", map[string]string{"X": "leaked"}, func(c *YamlConfig) any { return c.Container.Environment["A"] + ", " + c.Container.Environment["B"] }, "cost ${X}, cost ${X}", nil},
+		{"wrong type", "appName: svc\nport: ${PORT}\n", map[string]string{"PORT": "abc"}, nil, nil, []string{`${PORT} resolved to "abc", expected an integer, got str`}},
+		{"unset", "appName: svc\nrouter: ${ROUTER}\n", nil, nil, nil, []string{"environment variable ROUTER is not set, set it or give a default with ${ROUTER:-default}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, value := range tt.env {
+				t.Setenv(k, value)
+			}
+			filename := writeConfig(t, "talbot.yaml", tt.config)
+			conf, v, err := readConfig(filename, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var problems []string
+			for _, e := range v.errs {
+				problems = append(problems, e.Message)
+			}
+			if !reflect.DeepEqual(problems, tt.problems) {
+				t.Fatalf("Expected problems %q, got %q.", tt.problems, problems)
+			}
+			if tt.field != nil {
+				if got := tt.field(conf); got != tt.value {
+					t.Errorf("Expected %v, got %v.", tt.value, got)
+				}
+			}
+		})
+	}
+}
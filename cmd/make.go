@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -23,16 +24,34 @@ var makeCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		saveTo, err := cmd.Flags().GetString("save-config")
+		if err != nil {
+			return err
+		}
+		if saveTo != "" {
+			if err := saveConfig(saveTo, conf); err != nil {
+				return err
+			}
+		}
 		return makeAction(os.Stdout, conf)
 	},
 }
 
-func makeAction(out io.Writer, conf Config) error {
+func makeAction(out io.Writer, conf *YamlConfig) error {
+	if len(conf.Services) > 0 {
+		return makeMonorepo(out, conf)
+	}
+	return makeServer(out, conf, false)
+}
+
+// Makes a single server, either on its own or into the directory of a
+// monorepo, which contains the platform module shared by its servers
+func makeServer(out io.Writer, conf *YamlConfig, monorepo bool) error {
 	// Load in the information from the given configuration
 	// regardless of whether it was configured through YAML or flags
-	appName := conf.getAppName()
-	dir := conf.getDirectory()
-	modName := conf.getModName()
+	appName := conf.AppName
+	dir := conf.Directory
+	modName := conf.ModName
 	fmt.Printf("Creating new skeleton server named %s in %s\n", appName, dir)
 	// Check given directory
 	if err := checkDirectory(dir); err != nil {
@@ -44,7 +63,7 @@ func makeAction(out io.Writer, conf Config) error {
 		return err
 	}
 	// Create go mod
-	if err := initializeGoMod(modName, target); err != nil {
+	if err := initializeGoMod(modName, target, conf.Modules); err != nil {
 		return err
 	}
 	// Create README
@@ -69,25 +88,119 @@ func makeAction(out io.Writer, conf Config) error {
 		return err
 	}
 
-	if err := GenerateGoSourceFiles(target); err != nil {
+	data := NewTemplateData(conf)
+	if monorepo {
+		data.inMonorepo()
+	}
+	if data.GoVersion, err = readGoVersion(target); err != nil {
+		return err
+	}
+	if err := GenerateGoSourceFiles(target, data); err != nil {
+		return err
+	}
+	if data.NestedPlatform() {
+		if err := GeneratePlatformModule(target, data.Platform, conf.Modules.withDefaults()); err != nil {
+			return err
+		}
+		if err := writeFile(readme, "- `internal/platform`: Module of logging, error response, health check and middleware code, which can be published and imported by other servers\n"); err != nil {
+			return err
+		}
+	}
+
+	if err := GenerateContainerizationFiles(target, data); err != nil {
+		return err
+	}
+
+	if err := GenerateMakefile(target, data); err != nil {
 		return err
 	}
 
-	if err := GenerateContainerizationFiles(target); err != nil {
+	if err := GenerateDeploymentFiles(target, data); err != nil {
 		return err
 	}
 
-	if err := GetGolangPackage(target, "github.com/julienschmidt/httprouter"); err != nil {
+	if err := GenerateCIFiles(target, data); err != nil {
 		return err
 	}
 
+	if err := GenerateIgnoreFiles(target, data); err != nil {
+		return err
+	}
+
+	// Collect dependencies, from pinned versions when offline. The
+	// standard library router has no dependencies
+	modules := conf.Modules
+	if modules.Offline {
+		if err := writePinnedModules(target, data.Router); err != nil {
+			return err
+		}
+	} else if data.Router.Package != "" {
+		if err := GetGolangPackage(target, data.Router.Package); err != nil {
+			return err
+		}
+	}
+	if data.Platform != "" {
+		if err := requirePlatform(target, data); err != nil {
+			return err
+		}
+	}
+	if modules.Tidy {
+		if err := tidyModules(target, modules.Offline); err != nil {
+			return err
+		}
+	}
+	if modules.Vendor {
+		if err := vendorModules(target, modules.Offline); err != nil {
+			return err
+		}
+	}
+
 	// Update readme with healthcheck API info
 	if err := writeFile(readme, "\n## API Endpoints\n"); err != nil {
 		return err
 	}
-	if err := writeFile(readme, "| HTTP Endpoint | Method | Info |\n|-----|------|------|\n|`/v1/healthcheck`| GET | Displays server status\n"); err != nil {
+	if err := writeFile(readme, "| HTTP Endpoint | Method | Info |\n|-----|------|------|"); err != nil {
 		return err
 	}
+	for _, e := range data.Endpoints {
+		if err := writeFile(readme, e.GenerateReadmeTableEntry()); err != nil {
+			return err
+		}
+	}
+
+	// Update readme with configuration settings info
+	if err := writeFile(readme, "\n## Configuration\n"); err != nil {
+		return err
+	}
+	if err := writeFile(readme, "Settings are loaded from built-in defaults, an optional JSON file given by `-config` or `$CONFIG_FILE`, environment variables and flags, with later sources taking precedence. Run with `-print-config` to display the loaded settings with secrets redacted.\n"); err != nil {
+		return err
+	}
+	if err := writeFile(readme, "| Flag | Environment Variable | Type | Default | Info |\n|-----|------|------|------|------|"); err != nil {
+		return err
+	}
+	for _, c := range data.ConfigFields {
+		if err := writeFile(readme, c.GenerateReadmeTableEntry()); err != nil {
+			return err
+		}
+	}
+
+	// Update readme with build info
+	if err := writeFile(readme, "\n## Building\n"); err != nil {
+		return err
+	}
+	if err := writeFile(readme, "Run `make help` to list the available targets. `make build` compiles the server into `bin/` for this and every platform in `$PLATFORMS`, with its version, commit and build time injected through `-ldflags`, which are reported by `-version` and `/v1/healthcheck`.\n"); err != nil {
+		return err
+	}
+
+	// Update readme with deployment info
+	if deployment := DeploymentReadme(data); deployment != "" {
+		if err := writeFile(readme, "\n## Deployment\n"); err != nil {
+			return err
+		}
+		if err := writeFile(readme, deployment); err != nil {
+			return err
+		}
+	}
 
 	// Write a note about auto-generated documentation
 	if err := writeFile(readme, "\n## `talbot` disclaimer\n"); err != nil {
@@ -97,13 +210,36 @@ func makeAction(out io.Writer, conf Config) error {
 		return err
 	}
 
+	// Commit the finished project
+	if git := conf.Git; git.Init {
+		if err := initializeGitRepository(target, git.Author); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(makeCmd)
-	makeCmd.Flags().StringP("config", "c", "", "Configuration YAML file")
+	makeCmd.Flags().StringP("config", "c", "", "Configuration file (YAML, JSON or TOML), or - to read it from stdin")
+	makeCmd.Flags().String("config-format", "", "Format of the configuration file (yaml, json or toml, default detected from its extension)")
+	makeCmd.Flags().String("save-config", "", "Write the effective configuration to a YAML file which can be given to --config")
 	makeCmd.Flags().StringP("app-name", "n", "", "Name of application")
 	makeCmd.Flags().StringP("mod-name", "m", "", "Name of top-level application go module (default $app-name)")
 	makeCmd.Flags().StringP("dir", "d", "./", "Path to target app directory")
+	makeCmd.Flags().String("router", "httprouter", "Router requests are routed with ("+strings.Join(routerNames(), ", ")+")")
+	makeCmd.Flags().IntP("port", "p", defaultPort, "Port the generated server listens on")
+	makeCmd.Flags().String("container-profile", "scratch", "Runtime image of the container (scratch, distroless or alpine)")
+	makeCmd.Flags().String("go", "", "Go version of the go directive of the generated module (default local go version)")
+	makeCmd.Flags().String("toolchain", "", "Toolchain line of the generated module, (i.e. go1.22.3)")
+	makeCmd.Flags().Bool("tidy", false, "Run go mod tidy once generated")
+	makeCmd.Flags().Bool("offline", false, "Use pinned module versions rather than go get, without using the network")
+	makeCmd.Flags().Bool("vendor", false, "Copy dependencies into vendor/")
+	makeCmd.Flags().Bool("platform", false, "Generate a module of shared logging, error response, health check and middleware code into internal/platform, which the server imports")
+	makeCmd.Flags().String("platform-module", "", "Path of an existing platform module the server imports instead, (i.e. github.com/acme/platform)")
+	makeCmd.Flags().Bool("git", false, "Initialize a git repository with an initial commit")
+	makeCmd.Flags().String("git-author", "", "Author of the initial commit, (i.e. \"Jane Doe <jane@example.com>\")")
+	makeCmd.Flags().StringSlice("ci", []string{"github"}, "Providers to generate CI pipelines for (none, github or gitlab)")
+	makeCmd.Flags().String("deploy", "kubernetes", "Target to generate deployment files in remote for (none, kubernetes, helm or systemd)")
 }
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Endpoints whose paths and handler names are close to each other
+// or to the built-in endpoints, listed in the order every router
+// accepts them. Static paths come before parameters for gorilla
+const edgeCaseEndpoints = `
+endpoints:
+  - path: /v1/users/id
+    method: %s
+  - path: /v1/users/{id}
+  - path: /v1/users/{id}/posts
+  - path: /v1/users/{id}/posts
+    method: head
+  - path: /v1/health-check
+  - path: /2fa/codes
+    method: post
+`
+
+// Config fields whose defaults are written differently in Go
+const edgeCaseConfigFields = `
+config:
+  - name: verbose
+    type: bool
+    default: T
+  - name: maxWorkers
+    type: int
+    default: "08"
+  - name: ratio
+    type: float
+    default: 1e6
+  - name: threshold
+    type: float
+    default: 1_000.5
+  - name: cacheTtl
+    type: duration
+    default: 1m30s
+  - name: dbDsn
+    type: string
+    default: "postgres://localhost/db?sslmode=disable"
+`
+
+// runGo is a helper function that runs a go command in the directory
+// of a generated project, failing the test with its output
+func runGo(t *testing.T, dir string, args ...string) {
+	t.Helper() // Mark the function as test helper
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	// Generated projects are separate modules, which must not
+	// inherit the flags this module is tested with
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go %s failed in %s: %v\n%s", strings.Join(args, " "), dir, err, out)
+	}
+}
+
+// TestMakeEdgeCaseConfigs generates a server from each configuration
+// accepted at the edge of what the validator allows, and checks that the
+// generated code is vetted and its tests pass
+func TestMakeEdgeCaseConfigs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping generation of servers in short mode.")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("Skipping generation of servers without go.")
+	}
+	type edgeCase struct {
+		name   string
+		config string
+	}
+	tests := []edgeCase{
+		{"config-fields", edgeCaseConfigFields},
+		{"platform", "router: chi\nplatform:\n  generate: true\n  module: example.com/platform\n" + fmt.Sprintf(edgeCaseEndpoints, "get")},
+		{"default platform", "router: gin\nplatform:\n  generate: true\n"},
+	}
+	for _, router := range []string{"httprouter", "chi", "gorilla", "echo", "gin", "stdlib"} {
+		// httprouter can't serve a static and a parameter segment in the same
+		// position for one method, so the static path is served for another
+		method := "get"
+		if router == "httprouter" {
+			method = "post"
+		}
+		tests = append(tests, edgeCase{router, "router: " + router + "\n" + fmt.Sprintf(edgeCaseEndpoints, method)})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "talbot.yaml")
+			config := fmt.Sprintf("appName: edge-case\nmodName: example.com/edge-case\ndirectory: %s\nci: none\nmodules:\n  offline: true\n%s", dir, tt.config)
+			if err := os.WriteFile(filename, []byte(config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			conf, err := loadConfig(filename, nil)
+			if err != nil {
+				t.Fatalf("Expected configuration to be accepted, got %q.", err)
+			}
+			if err := makeAction(io.Discard, conf); err != nil {
+				t.Fatal(err)
+			}
+			target := filepath.Join(dir, conf.AppName)
+			runGo(t, target, "vet", "./...")
+			runGo(t, target, "test", "./...")
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+var MAKEFILE_BASE = `APP := {{.AppName}}
+
+# Build information injected into the binary at compile time
+VERSION ?= $(shell git describe --tags --always --dirty 2>/dev/null || echo dev)
+COMMIT ?= $(shell git rev-parse HEAD 2>/dev/null || echo unknown)
+BUILD_TIME ?= $(shell date -u +%Y-%m-%dT%H:%M:%SZ)
+LDFLAGS := -s -w -X main.version=$(VERSION) -X main.commit=$(COMMIT) -X main.buildTime=$(BUILD_TIME)
+
+# Platforms the server is cross-compiled for by build
+PLATFORMS ?= linux/amd64 linux/arm64 darwin/amd64 darwin/arm64 windows/amd64
+
+# Database the migrations are applied to, read from the environment of the server
+MIGRATE_DSN ?= $({{.DatabaseEnv}})
+
+.DEFAULT_GOAL := help
+
+# ==================================================================================== #
+# HELPERS
+# ==================================================================================== #
+
+## help: print this help message
+.PHONY: help
+help:
+	@echo 'Usage:'
+	@awk '/^## / { line = substr($$0, 4); i = index(line, ": "); printf "  %-28s %s\n", substr(line, 1, i - 1), substr(line, i + 2) }' $(MAKEFILE_LIST)
+
+.PHONY: confirm
+confirm:
+	@echo -n 'Are you sure? [y/N] ' && read ans && [ $${ans:-N} = y ]
+
+# ==================================================================================== #
+# DEVELOPMENT
+# ==================================================================================== #
+
+## run: run the cmd/api application
+.PHONY: run
+run:
+	go run ./cmd/api
+
+## test: run all tests with the race detector and report coverage
+.PHONY: test
+test:
+	go test -race -coverprofile=coverage.out ./...
+	go tool cover -func=coverage.out
+{{- if .NestedPlatform}}
+	cd internal/platform && go test -race ./...
+{{- end}}
+
+## audit: verify modules, check formatting, vet and test all code
+.PHONY: audit
+audit:
+	go mod tidy
+{{- if .Vendor}}
+	go mod vendor
+{{- end}}
+	go mod verify
+	@test -z "$$(gofmt -l .)" || (echo 'unformatted files:' && gofmt -l . && exit 1)
+	go vet ./...
+	@if command -v staticcheck >/dev/null 2>&1; then staticcheck ./...; else echo 'staticcheck not installed, skipping'; fi
+	go test -race -vet=off ./...
+{{- if .NestedPlatform}}
+	cd internal/platform && go mod tidy && go mod verify && go vet ./... && go test -race -vet=off ./...
+{{- end}}
+
+## migrate/new name=$1: create a new database migration
+.PHONY: migrate/new
+migrate/new:
+	migrate create -seq -ext=.sql -dir=./migrations $(name)
+
+## migrate: apply all up database migrations to $(MIGRATE_DSN)
+.PHONY: migrate
+migrate: confirm
+	migrate -path=./migrations -database=$(MIGRATE_DSN) up
+
+# ==================================================================================== #
+# BUILD
+# ==================================================================================== #
+
+## build: build the cmd/api application into bin/ for this and every other platform
+.PHONY: build
+build:
+	CGO_ENABLED=0 go build -trimpath -ldflags="$(LDFLAGS)" -o=./bin/$(APP) ./cmd/api
+	@for platform in $(PLATFORMS); do \
+		os=$${platform%/*}; arch=$${platform#*/}; ext=; \
+		[ "$$os" = windows ] && ext=.exe; \
+		echo "building bin/$${os}_$${arch}/$(APP)$$ext"; \
+		CGO_ENABLED=0 GOOS=$$os GOARCH=$$arch go build -trimpath -ldflags="$(LDFLAGS)" \
+			-o=./bin/$${os}_$${arch}/$(APP)$$ext ./cmd/api || exit 1; \
+	done
+
+## docker: build the container image with build information
+.PHONY: docker
+docker:
+	DOCKER_BUILDKIT=1 docker build \
+		--build-arg VERSION=$(VERSION) \
+		--build-arg COMMIT=$(COMMIT) \
+		--build-arg BUILD_TIME=$(BUILD_TIME) \
+		-t $(APP):$(VERSION) {{if .ServerDir}}-f Dockerfile {{end}}{{.BuildContext}}
+
+## compose: run the server{{if .Services}} and its dependent services{{end}} with docker-compose
+.PHONY: compose
+compose:
+	docker compose up --build
+{{- if eq .Deploy "kubernetes"}}
+
+# ==================================================================================== #
+# DEPLOYMENT
+# ==================================================================================== #
+
+# Kustomize overlay deployed to, (i.e. make deploy OVERLAY=prod)
+OVERLAY ?= dev
+
+## deploy: apply the Kubernetes manifests of $(OVERLAY)
+.PHONY: deploy
+deploy: confirm
+	kubectl apply -k remote/kubernetes/overlays/$(OVERLAY)
+{{- else if eq .Deploy "helm"}}
+
+# ==================================================================================== #
+# DEPLOYMENT
+# ==================================================================================== #
+
+## deploy: install or upgrade the Helm chart
+.PHONY: deploy
+deploy: confirm
+	helm upgrade --install $(APP) remote/chart --set image.tag=$(VERSION)
+{{- else if eq .Deploy "systemd"}}
+
+# ==================================================================================== #
+# DEPLOYMENT
+# ==================================================================================== #
+
+## deploy: install the server as a systemd service on this host
+.PHONY: deploy
+deploy: confirm build
+	sudo ./remote/systemd/setup.sh
+{{- end}}
+`
+
+// Creates Makefile
+func GenerateMakefile(target string, data TemplateData) error {
+	// Create Makefile
+	makefile, err := createFile("Makefile", target)
+	if err != nil {
+		return err
+	}
+	// Write Makefile content to newly created file
+	if err := writeTemplate(makefile, MAKEFILE_BASE, data); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Version the generated platform module is required at, which
+// is never published as it is replaced by its directory
+const platformVersion = "v0.0.0"
+
+// Requirements and checksums of the modules each router depends on,
+// pinned so that servers can be generated without the network
+//
+//go:embed modules
+var pinnedModules embed.FS
+
+// ModuleDefinition contains information about how the
+// dependencies of the generated server are collected
+type ModuleDefinition struct {
+	Go        string `yaml:"go" json:"go"`               // Go directive, defaults to the local go version
+	Toolchain string `yaml:"toolchain" json:"toolchain"` // Optional toolchain line, (i.e. go1.22.3)
+	Offline   bool   `yaml:"offline" json:"offline"`     // Use pinned versions rather than go get
+	Vendor    bool   `yaml:"vendor" json:"vendor"`       // Copy dependencies into vendor/
+	Tidy      bool   `yaml:"tidy" json:"tidy"`           // Run go mod tidy once generated
+}
+
+// Returns the module settings with the go directive defaulted
+func (m ModuleDefinition) withDefaults() ModuleDefinition {
+	if m.Go == "" {
+		m.Go = localGoVersion()
+	}
+	return m
+}
+
+// Checks that the go directive and toolchain line are valid
+// and that the go version supports the generated server
+func (m ModuleDefinition) validate() error {
+	var p problems
+	if !modfile.GoVersionRE.MatchString(m.Go) {
+		p.addf("go", "invalid go version %q, must be a release version (i.e. 1.22.3)", m.Go)
+		return p.err()
+	}
+	// Handlers read path parameters through r.PathValue
+	if !goVersionAtLeast(m.Go, "1.22") {
+		p.addf("go", "generated servers require go 1.22 or later, found go %s", m.Go)
+	}
+	if m.Toolchain == "" {
+		return p.err()
+	}
+	if !modfile.ToolchainRE.MatchString(m.Toolchain) {
+		p.addf("toolchain", "invalid toolchain %q, must be default or a toolchain name (i.e. go1.22.3)", m.Toolchain)
+	} else if m.Toolchain != "default" && !goVersionAtLeast(strings.TrimPrefix(m.Toolchain, "go"), m.Go) {
+		p.addf("toolchain", "toolchain %s is older than go version %s", m.Toolchain, m.Go)
+	}
+	return p.err()
+}
+
+// Checks that the pinned modules of the router can be used
+// with the go version when generating offline
+func (m ModuleDefinition) validatePinned(router string) error {
+	backend, ok := routerBackends[router]
+	if !m.Offline || !ok || backend.Package == "" {
+		return nil
+	}
+	goVersion, _, err := pinnedRequirements(router)
+	if err != nil {
+		return err
+	}
+	if !goVersionAtLeast(m.Go, goVersion) {
+		return fieldError{"go", fmt.Errorf("pinned modules of router %s require go %s or later, found go %s", router, goVersion, m.Go)}
+	}
+	return nil
+}
+
+// Returns the version of the local go toolchain, or the version talbot was
+// built with if go isn't installed, (i.e. go1.22.3 X:boringcrypto -> 1.22.3)
+func localGoVersion() string {
+	version := runtime.Version()
+	if out, err := exec.Command("go", "env", "GOVERSION").Output(); err == nil {
+		version = string(out)
+	}
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(version), "go"))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// Checks that the module path is valid, allowing paths without a domain
+// for modules which won't be downloaded as go mod init does
+func validateModulePath(modName string) error {
+	first := strings.SplitN(modName, "/", 2)[0]
+	if strings.Contains(first, ".") {
+		return module.CheckPath(modName)
+	}
+	return module.CheckImportPath(modName)
+}
+
+// Returns the pinned minimum go version, and requirements of
+// the router, (i.e. github.com/go-chi/chi/v5 v5.3.2)
+func pinnedRequirements(router string) (string, []string, error) {
+	content, err := pinnedModules.ReadFile(path.Join("modules", router, "requirements.txt"))
+	if err != nil {
+		return "", nil, fmt.Errorf("no pinned modules for router %s: %w", router, err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	return strings.TrimPrefix(lines[0], "go "), lines[1:], nil
+}
+
+// Adds the pinned requirements of the router to go.mod and writes
+// their checksums to go.sum, without using the network
+func writePinnedModules(target string, router RouterBackend) error {
+	// The standard library router has no dependencies
+	if router.Package == "" {
+		return nil
+	}
+	fmt.Printf("Adding pinned modules of %s to %s...\n", router.Name, target)
+	goVersion, requirements, err := pinnedRequirements(router.Name)
+	if err != nil {
+		return err
+	}
+	f, err := readGoMod(target)
+	if err != nil {
+		return err
+	}
+	if !goVersionAtLeast(f.Go.Version, goVersion) {
+		fmt.Printf("--> Couldn't add pinned modules of %s, aborting.\n", router.Name)
+		return fmt.Errorf("pinned modules of router %s require go %s or later, found go %s, set the go version of the module to at least %s", router.Name, goVersion, f.Go.Version, goVersion)
+	}
+	// Lay out requirements as go mod tidy does, with indirect
+	// requirements in their own block
+	var requires []*modfile.Require
+	for _, r := range requirements {
+		fields := strings.Fields(r)
+		requires = append(requires, &modfile.Require{
+			Mod:      module.Version{Path: fields[0], Version: fields[1]},
+			Indirect: strings.HasSuffix(r, "// indirect"),
+		})
+	}
+	f.SetRequireSeparateIndirect(requires)
+	content, err := f.Format()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(target, "go.mod"), content, 0644); err != nil {
+		return err
+	}
+	sum, err := pinnedModules.ReadFile(path.Join("modules", router.Name, "go.sum"))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(target, "go.sum"), sum, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("--> Successfully added pinned modules of %s, continuing.\n", router.Name)
+	return nil
+}
+
+// Requires the platform module in the go.mod of the generated server,
+// replacing it with the generated module or collecting its version
+func requirePlatform(target string, data TemplateData) error {
+	if data.PlatformDir == "" {
+		return GetGolangPackage(target, data.Platform+"@"+data.PlatformVersion)
+	}
+	fmt.Printf("Requiring platform module %s in %s...\n", data.Platform, target)
+	f, err := readGoMod(target)
+	if err != nil {
+		return err
+	}
+	// Require the module alongside the other direct dependencies rather
+	// than in the last block, which lists indirect dependencies
+	requires := append(f.Require, &modfile.Require{Mod: module.Version{Path: data.Platform, Version: platformVersion}})
+	f.SetRequireSeparateIndirect(requires)
+	// Replace a single version, as go workspaces reject replacements
+	// of their modules at every version
+	if err := f.AddReplace(data.Platform, platformVersion, data.PlatformDir, ""); err != nil {
+		return err
+	}
+	content, err := f.Format()
+	if err == nil {
+		err = os.WriteFile(filepath.Join(target, "go.mod"), content, 0644)
+	}
+	if err != nil {
+		fmt.Printf("--> Couldn't require platform module %s, aborting.\n", data.Platform)
+		return err
+	}
+	fmt.Printf("--> Successfully required platform module %s, continuing.\n", data.Platform)
+	return nil
+}
+
+// Copies the dependencies of the generated server into vendor/,
+// only reading from the local module cache when offline
+func vendorModules(target string, offline bool) error {
+	fmt.Printf("Vendoring go modules in %s...\n", target)
+	if out, err := goModCommand(target, offline, "vendor").CombinedOutput(); err != nil {
+		fmt.Printf("--> Couldn't vendor go modules, aborting.\n%s", out)
+		return err
+	}
+	fmt.Printf("--> Successfully vendored go modules in %s, continuing.\n", target)
+	return nil
+}
+
+// Adds missing and removes unused requirements of the generated
+// server, only reading from the local module cache when offline
+func tidyModules(target string, offline bool) error {
+	fmt.Printf("Tidying go module in %s...\n", target)
+	if out, err := goModCommand(target, offline, "tidy").CombinedOutput(); err != nil {
+		fmt.Printf("--> Couldn't tidy go module, aborting.\n%s", out)
+		return err
+	}
+	fmt.Printf("--> Successfully tidied go module in %s, continuing.\n", target)
+	return nil
+}
+
+// Returns a go mod command run in target, which is
+// prevented from using the network when offline
+func goModCommand(target string, offline bool, args ...string) *exec.Cmd {
+	cmd := exec.Command("go", append([]string{"mod"}, args...)...)
+	cmd.Dir = target
+	cmd.Env = os.Environ()
+	if offline {
+		cmd.Env = append(cmd.Env, "GOPROXY=off", "GOTOOLCHAIN=local")
+	}
+	return cmd
+}
@@ -0,0 +1,850 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+var PLATFORM_LOGGING_BASE = `package platform
+
+import (
+	"log"
+	"os"
+)
+
+// NewLogger returns a logger writing timestamped messages to stdout,
+// prefixed with the name of the service so that the output of
+// services run together can be told apart
+func NewLogger(service string) *log.Logger {
+	return log.New(os.Stdout, service+": ", log.Ldate|log.Ltime|log.Lmsgprefix)
+}
+`
+
+var PLATFORM_ERRORS_BASE = `package platform
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// Envelope wraps JSON responses in a top-level object
+type Envelope map[string]any
+
+// ReplyText wraps text content in a HTTP response and sends it
+func ReplyText(w http.ResponseWriter, r *http.Request, status int, content string) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(status)
+	w.Write([]byte(content + "\n"))
+}
+
+// ReplyJSON encodes data as JSON in a HTTP response and sends it
+func ReplyJSON(w http.ResponseWriter, r *http.Request, status int, data any) {
+	js, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		ReplyText(w, r, http.StatusInternalServerError, "couldn't encode response")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(js, '\n'))
+}
+
+// ReplyError replies with the status and a message describing
+// the error in a JSON envelope, (i.e. {"error": "user not found"})
+func ReplyError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	ReplyJSON(w, r, status, Envelope{"error": message})
+}
+
+// ServerError logs err and replies with a 500 status, without
+// exposing the details of err to the client
+func ServerError(logger *log.Logger, w http.ResponseWriter, r *http.Request, err error) {
+	logger.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+	ReplyError(w, r, http.StatusInternalServerError, "the server encountered a problem")
+}
+`
+
+var PLATFORM_MIDDLEWARE_BASE = `package platform
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// RecoverPanic replies with a 500 status rather than dropping
+// the connection when a handler panics, logging the panic
+func RecoverPanic(logger *log.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				w.Header().Set("Connection", "close")
+				logger.Print(fmt.Errorf("recovered from panic: %v", err))
+				ReplyText(w, r, http.StatusInternalServerError, "the server encountered a problem")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+`
+
+var PLATFORM_HEALTH_BASE = `package platform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// CheckerFunc reports whether a single dependency is healthy
+type CheckerFunc func(ctx context.Context) error
+
+// healthCheck is a named dependency check with its own timeout
+type healthCheck struct {
+	name    string
+	timeout time.Duration
+	check   CheckerFunc
+}
+
+// CheckResult describes the outcome of a single dependency check
+type CheckResult struct {
+	Status   string ` + "`" + `json:"status"` + "`" + `
+	Error    string ` + "`" + `json:"error,omitempty"` + "`" + `
+	Duration string ` + "`" + `json:"duration"` + "`" + `
+}
+
+// HealthRegistry contains the dependency checks run by readiness endpoints
+type HealthRegistry struct {
+	mu     sync.RWMutex
+	checks []healthCheck
+}
+
+// NewHealthRegistry returns an empty health registry
+func NewHealthRegistry() *HealthRegistry {
+	return &HealthRegistry{}
+}
+
+// Register adds a dependency check to the registry
+func (h *HealthRegistry) Register(name string, timeout time.Duration, check CheckerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, healthCheck{name: name, timeout: timeout, check: check})
+}
+
+// Run executes all registered checks concurrently and returns the result
+// of each check along with whether all of them passed
+func (h *HealthRegistry) Run(ctx context.Context) (map[string]CheckResult, bool) {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]CheckResult, len(checks))
+	healthy := true
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c healthCheck) {
+			defer wg.Done()
+			start := time.Now()
+			err := runWithTimeout(ctx, c.timeout, c.check)
+			res := CheckResult{Status: "pass", Duration: time.Since(start).String()}
+			if err != nil {
+				res.Status, res.Error = "fail", err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[c.name] = res
+			healthy = healthy && err == nil
+		}(c)
+	}
+	wg.Wait()
+	return results, healthy
+}
+
+// runWithTimeout runs a check, giving up once the timeout has
+// elapsed even if the check does not respect its context
+func runWithTimeout(ctx context.Context, timeout time.Duration, check CheckerFunc) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pinger is satisfied by *sql.DB and most database clients
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DatabaseChecker checks that a database connection is alive
+func DatabaseChecker(db Pinger) CheckerFunc {
+	return db.PingContext
+}
+
+// HTTPChecker checks that a downstream HTTP dependency replies with a 2xx status
+func HTTPChecker(url string) CheckerFunc {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+		}
+		return nil
+	}
+}
+
+// DiskChecker checks that a directory exists and is writable
+func DiskChecker(dir string) CheckerFunc {
+	return func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		return os.Remove(f.Name())
+	}
+}
+`
+
+var PLATFORM_TEST_BASE = `package platform
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReplyError checks that errors are replied as JSON with their status
+func TestReplyError(t *testing.T) {
+	w := httptest.NewRecorder()
+	ReplyError(w, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusNotFound, "user not found")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected %q, got %q.", http.StatusText(http.StatusNotFound), http.StatusText(w.Code))
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content, got %q.", ct)
+	}
+	if !strings.Contains(w.Body.String(), "user not found") {
+		t.Errorf("Expected error message, got %q.", w.Body.String())
+	}
+}
+
+// TestRecoverPanic checks that panicking handlers reply with a 500 status
+func TestRecoverPanic(t *testing.T) {
+	handler := RecoverPanic(log.New(io.Discard, "", 0), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("unexpected failure")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected %q, got %q.", http.StatusText(http.StatusInternalServerError), http.StatusText(w.Code))
+	}
+}
+
+// TestHealthRegistry checks that failing and hanging checks
+// fail the registry, and that passing checks are reported
+func TestHealthRegistry(t *testing.T) {
+	h := NewHealthRegistry()
+	h.Register("passing", time.Second, func(ctx context.Context) error {
+		return nil
+	})
+	if results, ok := h.Run(context.Background()); !ok || results["passing"].Status != "pass" {
+		t.Fatalf("Expected passing checks, got %v.", results)
+	}
+	h.Register("failing", time.Second, func(ctx context.Context) error {
+		return errors.New("dependency unreachable")
+	})
+	h.Register("hanging", 10*time.Millisecond, func(ctx context.Context) error {
+		select {}
+	})
+	results, ok := h.Run(context.Background())
+	if ok {
+		t.Fatal("Expected failing checks, got none.")
+	}
+	if results["failing"].Error != "dependency unreachable" {
+		t.Errorf("Expected failing check error, got %q.", results["failing"].Error)
+	}
+	if !strings.Contains(results["hanging"].Error, "deadline exceeded") {
+		t.Errorf("Expected hanging check to time out, got %q.", results["hanging"].Error)
+	}
+}
+`
+
+var MONOREPO_DOCKER_COMPOSE_BASE = `version: "3.9"
+
+# Servers reach each other and the services they depend on by name
+networks:
+  {{.AppName}}:
+
+services:
+{{- range .Servers}}
+  {{.AppName}}:
+    image: {{.AppName}}
+    build:
+      context: .
+      dockerfile: {{.AppName}}/Dockerfile
+      args:
+        VERSION: ${VERSION:-dev}
+        COMMIT: ${COMMIT:-unknown}
+        BUILD_TIME: ${BUILD_TIME:-unknown}
+        RUN_TESTS: ${RUN_TESTS:-true}
+    ports:
+      - "{{.Port}}:{{.Port}}"
+    # Harden the runtime container, mounting a writable /tmp only
+    read_only: true
+    tmpfs:
+      - /tmp
+    cap_drop:
+      - ALL
+    security_opt:
+      - no-new-privileges:true
+    networks:
+      - {{$.AppName}}
+    environment:
+{{- range $name, $value := .Environment}}
+      {{$name}}: {{printf "%q" $value}}
+{{- end}}
+{{- if .Services}}
+    depends_on:
+{{- range .Services}}
+      - {{.Name}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- range .Dependencies}}
+
+  {{.Name}}:
+    image: {{.Image}}
+    networks:
+      - {{$.AppName}}
+{{- if .Ports}}
+    ports:
+{{- range .Ports}}
+      - {{printf "%q" .}}
+{{- end}}
+{{- end}}
+{{- if .Environment}}
+    environment:
+{{- range $name, $value := .Environment}}
+      {{$name}}: {{printf "%q" $value}}
+{{- end}}
+{{- end}}
+{{- end}}
+`
+
+var MONOREPO_MAKEFILE_BASE = `# Servers of the monorepo, each with a Makefile of its own
+SERVICES := {{range $i, $s := .Servers}}{{if $i}} {{end}}{{$s.AppName}}{{end}}
+
+# Runs the target of the same name in every server
+FAN_OUT = @for service in $(SERVICES); do $(MAKE) -C $$service $@ || exit 1; done
+
+.DEFAULT_GOAL := help
+
+# ==================================================================================== #
+# HELPERS
+# ==================================================================================== #
+
+## help: print this help message
+.PHONY: help
+help:
+	@echo 'Usage:'
+	@awk '/^## / { line = substr($$0, 4); i = index(line, ": "); printf "  %-28s %s\n", substr(line, 1, i - 1), substr(line, i + 2) }' $(MAKEFILE_LIST)
+
+# ==================================================================================== #
+# DEVELOPMENT
+# ==================================================================================== #
+
+{{- if .GeneratedPlatform}}
+
+## test: run the tests of the platform module and every server
+.PHONY: test
+test:
+	cd internal/platform && go test -race ./...
+	$(FAN_OUT)
+
+## audit: verify, vet and test the platform module and every server
+.PHONY: audit
+audit:
+	cd internal/platform && go mod tidy && go mod verify && go vet ./... && go test -race -vet=off ./...
+	$(FAN_OUT)
+{{- else}}
+
+## test: run the tests of every server
+.PHONY: test
+test:
+	$(FAN_OUT)
+
+## audit: verify, vet and test every server
+.PHONY: audit
+audit:
+	$(FAN_OUT)
+{{- end}}
+
+# ==================================================================================== #
+# BUILD
+# ==================================================================================== #
+
+## build: build every server into its bin/ directory
+.PHONY: build
+build:
+	$(FAN_OUT)
+
+## docker: build the container image of every server
+.PHONY: docker
+docker:
+	$(FAN_OUT)
+
+## compose: run every server{{if .Dependencies}} and their dependent services{{end}} together with docker-compose
+.PHONY: compose
+compose:
+	docker compose up --build
+`
+
+// GitHub Actions expressions share their delimiters with Go templates,
+// so they are written as template strings
+var MONOREPO_CI_GITHUB_BASE = `name: "CI"
+
+on:
+  push:
+    branches: [ main ]
+  pull_request:
+    branches: [ main ]
+
+jobs:
+  test:
+    runs-on: ubuntu-latest
+
+    strategy:
+      matrix:
+        module: [ {{if .GeneratedPlatform}}internal/platform, {{end}}{{range $i, $s := .Servers}}{{if $i}}, {{end}}{{$s.AppName}}{{end}} ]
+
+    defaults:
+      run:
+        working-directory: {{"${{ matrix.module }}"}}
+
+    steps:
+    - name: Checkout code
+      uses: actions/checkout@v4
+
+    - name: Set up Go
+      uses: actions/setup-go@v5
+      with:
+        go-version-file: go.work
+
+    - name: Build
+      run: go build -v ./...
+
+    - name: Vet
+      run: go vet ./...
+
+    - name: Test with race detector
+      run: go test -race ./...
+
+  docker:
+    needs: test
+    runs-on: ubuntu-latest
+
+    strategy:
+      matrix:
+        service: [ {{range $i, $s := .Servers}}{{if $i}}, {{end}}{{$s.AppName}}{{end}} ]
+
+    steps:
+    - name: Checkout code
+      uses: actions/checkout@v4
+
+    - name: Set up Docker Buildx
+      uses: docker/setup-buildx-action@v3
+
+    - name: Build image
+      uses: docker/build-push-action@v6
+      with:
+        # Images are built from the monorepo so that the platform module can be copied
+        context: .
+        file: {{"${{ matrix.service }}/Dockerfile"}}
+        push: false
+        tags: {{"${{ matrix.service }}:${{ github.sha }}"}}
+        # Tests have already run in the test job
+        build-args: |
+          VERSION={{"${{ github.ref_name }}"}}
+          COMMIT={{"${{ github.sha }}"}}
+          RUN_TESTS=false
+        cache-from: type=gha,scope={{"${{ matrix.service }}"}}
+        cache-to: type=gha,mode=max,scope={{"${{ matrix.service }}"}}
+`
+
+var MONOREPO_CI_GITLAB_BASE = `stages:
+  - test
+  - docker
+
+variables:
+  # Keep the module cache inside the project so it can be cached
+  GOPATH: $CI_PROJECT_DIR/.go
+
+default:
+  image: golang:{{.GoVersion}}
+  cache:
+    key: go-modules
+    paths:
+      - .go/pkg/mod/
+
+test:
+  stage: test
+  parallel:
+    matrix:
+      - MODULE: [ {{if .GeneratedPlatform}}internal/platform, {{end}}{{range $i, $s := .Servers}}{{if $i}}, {{end}}{{$s.AppName}}{{end}} ]
+  script:
+    - cd $MODULE
+    - go build -v ./...
+    - go vet ./...
+    - go test -race ./...
+
+docker:
+  stage: docker
+  image: docker:24
+  services:
+    - docker:24-dind
+  variables:
+    DOCKER_BUILDKIT: "1"
+  cache: []
+  parallel:
+    matrix:
+      - SERVICE: [ {{range $i, $s := .Servers}}{{if $i}}, {{end}}{{$s.AppName}}{{end}} ]
+  script:
+    # Tests have already run in the test stage, and images are built
+    # from the monorepo so that the platform module can be copied
+    - docker build
+      --build-arg VERSION=$CI_COMMIT_REF_NAME
+      --build-arg COMMIT=$CI_COMMIT_SHA
+      --build-arg RUN_TESTS=false
+      -t $SERVICE:$CI_COMMIT_SHORT_SHA -f $SERVICE/Dockerfile .
+`
+
+var MONOREPO_DOCKERIGNORE_BASE = `# Images are built from the monorepo, but only the go.mod, go.sum and
+# cmd/api of a server{{if .GeneratedPlatform}} and the platform module{{end}} are needed to build them
+.git
+.github
+.gitlab-ci.yml
+go.work
+go.work.sum
+**/bin/
+**/remote/
+**/migrations/
+**/.env
+**/.env.*
+**/coverage.out
+**/*.md
+**/Makefile
+**/docker-compose.yaml
+`
+
+var MONOREPO_README_BASE = `# {{.AppName}}
+
+Monorepo of the {{.AppName}} servers, developed together in a go workspace.
+
+## Servers
+
+| Server | Module | Port |
+|-----|------|------|
+{{- range .Servers}}
+| [{{.AppName}}]({{.AppName}}) | ` + "`{{.ModName}}`" + ` | {{.Port}} |
+{{- end}}
+
+## File Structure
+
+{{- if .GeneratedPlatform}}
+- ` + "`go.work`" + `: Go workspace of the platform module and every server, so that changes to the platform module are used by the servers without publishing it
+- ` + "`internal/platform`" + `: Module of logging, error response, health check and middleware code imported by the servers as ` + "`{{.Platform}}`" + `
+{{- else}}
+- ` + "`go.work`" + `: Go workspace of every server
+{{- end}}
+- ` + "`docker-compose.yaml`" + `: Runs every server{{if .Dependencies}}, and the services they depend on,{{end}} on the ` + "`{{.AppName}}`" + ` network, where servers reach each other by name through ` + "`$<SERVER>_URL`" + `, (i.e. ` + "`http://{{(index .Servers 0).AppName}}:{{(index .Servers 0).Port}}`" + `)
+{{- range .Servers}}
+- ` + "`{{.AppName}}`" + `: The {{.AppName}} server, with a README, Makefile and deployment files of its own
+{{- end}}
+
+## Building
+
+Run ` + "`make help`" + ` to list the available targets. ` + "`make build`" + `, ` + "`make test`" + `, ` + "`make audit`" + ` and ` + "`make docker`" + ` run the target of the same name in every server, and ` + "`make compose`" + ` runs all of them together. Targets of a single server are run from its directory, (i.e. ` + "`make -C {{(index .Servers 0).AppName}} run`" + `).
+
+## ` + "`talbot`" + ` disclaimer
+
+This README has been autogenerated by [talbot](https://github.com/rohitkochhar/talbot)
+`
+
+// MonorepoData contains the values used to render
+// the files shared by the servers of a monorepo
+type MonorepoData struct {
+	AppName      string
+	ModName      string
+	GoVersion    string              // Go version of the go directive of go.work
+	Servers      []TemplateData      // Servers in the order they are configured
+	Dependencies []ServiceDefinition // Services run alongside the servers by docker-compose
+	CI           []string            // Providers CI pipelines are generated for
+	Platform     string              // Path of the platform module the servers import
+	// Whether the platform module is generated into internal/platform
+	// rather than being an existing module
+	GeneratedPlatform bool
+}
+
+// Collects the values used to render the files shared by the servers
+// of the monorepo, whose configurations are given in order
+func NewMonorepoData(conf *YamlConfig, servers []*YamlConfig) MonorepoData {
+	platform := conf.Platform
+	data := MonorepoData{
+		AppName:           conf.AppName,
+		ModName:           conf.ModName,
+		GoVersion:         conf.Modules.withDefaults().Go,
+		CI:                conf.CI,
+		Platform:          platform.Module,
+		GeneratedPlatform: platform.Generate,
+	}
+	seen := map[string]bool{}
+	for _, s := range servers {
+		server := NewTemplateData(s)
+		server.inMonorepo()
+		// Wire every server to the others through the network, unless
+		// the address is set explicitly
+		env := map[string]string{}
+		for k, v := range server.Environment {
+			env[k] = v
+		}
+		for _, other := range servers {
+			name := strings.ToUpper(strings.ReplaceAll(other.AppName, "-", "_")) + "_URL"
+			if _, ok := s.Container.Environment[name]; !ok && other != s {
+				env[name] = fmt.Sprintf("http://%s:%d", other.AppName, other.Port)
+			}
+		}
+		server.Environment = env
+		data.Servers = append(data.Servers, server)
+		for _, dep := range server.Services {
+			if !seen[dep.Name] {
+				seen[dep.Name] = true
+				data.Dependencies = append(data.Dependencies, dep)
+			}
+		}
+	}
+	return data
+}
+
+// Makes a monorepo with a server for each configured service, sharing a
+// go workspace, platform module, docker-compose file and Makefile. The
+// platform module is generated unless an existing module is configured
+func makeMonorepo(out io.Writer, conf *YamlConfig) error {
+	appName := conf.AppName
+	dir := conf.Directory
+	fmt.Printf("Creating new monorepo named %s with %d servers in %s\n", appName, len(conf.Services), dir)
+	if err := checkDirectory(dir); err != nil {
+		return err
+	}
+	target := filepath.Join(dir, appName)
+	if err := createTargetDirectory(target); err != nil {
+		return err
+	}
+	modules := conf.Modules.withDefaults()
+	if platform := conf.Platform; platform.Generate {
+		if err := GeneratePlatformModule(target, platform.Module, modules); err != nil {
+			return err
+		}
+	}
+
+	// Servers are generated before the workspace exists, so that their
+	// dependencies are collected as they would be for a single server
+	var servers []*YamlConfig
+	for _, s := range conf.Services {
+		server := serverConfig(conf, s, target)
+		if err := makeServer(out, server, true); err != nil {
+			return err
+		}
+		servers = append(servers, server)
+	}
+
+	data := NewMonorepoData(conf, servers)
+	if err := GenerateWorkspace(target, data, modules); err != nil {
+		return err
+	}
+	if err := GenerateMonorepoFiles(target, data); err != nil {
+		return err
+	}
+	if err := GenerateMonorepoCIFiles(target, data); err != nil {
+		return err
+	}
+	gitignore, err := createFile(".gitignore", target)
+	if err != nil {
+		return err
+	}
+	if err := writeTemplate(gitignore, GITIGNORE_BASE, data); err != nil {
+		return err
+	}
+	dockerignore, err := createFile(".dockerignore", target)
+	if err != nil {
+		return err
+	}
+	if err := writeTemplate(dockerignore, MONOREPO_DOCKERIGNORE_BASE, data); err != nil {
+		return err
+	}
+
+	// Commit the finished monorepo
+	if git := conf.Git; git.Init {
+		if err := initializeGitRepository(target, git.Author); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the configuration of a server of the monorepo, generated into
+// the monorepo directory with the settings and platform module shared by
+// every server. CI pipelines and the git repository belong to the monorepo instead
+func serverConfig(conf *YamlConfig, s ServerDefinition, dir string) *YamlConfig {
+	return &YamlConfig{
+		AppName:      s.Name,
+		Directory:    dir,
+		ModName:      s.ModName,
+		Router:       s.Router,
+		Endpoints:    s.Endpoints,
+		HealthChecks: s.HealthChecks,
+		ConfigFields: s.ConfigFields,
+		Port:         s.Port,
+		Container:    s.Container,
+		Deploy:       conf.Deploy,
+		Kubernetes:   conf.Kubernetes,
+		Systemd:      conf.Systemd.withDefaults(s.Name),
+		CI:           StringList{"none"},
+		Modules:      conf.Modules,
+		Platform:     conf.Platform,
+	}
+}
+
+// Creates the platform module of code shared by servers in internal/platform
+func GeneratePlatformModule(target, modName string, modules ModuleDefinition) error {
+	platform := filepath.Join(target, "internal", "platform")
+	if err := createDirectories(target, "internal/platform"); err != nil {
+		return err
+	}
+	if err := initializeGoMod(modName, platform, modules); err != nil {
+		return err
+	}
+	for _, f := range []struct{ name, content string }{
+		{"logging.go", PLATFORM_LOGGING_BASE},
+		{"errors.go", PLATFORM_ERRORS_BASE},
+		{"middleware.go", PLATFORM_MIDDLEWARE_BASE},
+		{"health.go", PLATFORM_HEALTH_BASE},
+		{"platform_test.go", PLATFORM_TEST_BASE},
+	} {
+		file, err := createFile(f.name, platform)
+		if err != nil {
+			return err
+		}
+		if err := writeTemplate(file, f.content, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Creates go.work using the generated platform module and every server
+func GenerateWorkspace(target string, data MonorepoData, modules ModuleDefinition) error {
+	fmt.Printf("Creating go workspace in %s...\n", target)
+	f, err := modfile.ParseWork("go.work", nil, nil)
+	if err != nil {
+		return err
+	}
+	if err := f.AddGoStmt(data.GoVersion); err != nil {
+		return err
+	}
+	if modules.Toolchain != "" {
+		if err := f.AddToolchainStmt(modules.Toolchain); err != nil {
+			return err
+		}
+	}
+	var uses []*modfile.Use
+	if data.GeneratedPlatform {
+		uses = append(uses, &modfile.Use{Path: "./internal/platform"})
+	}
+	for _, s := range data.Servers {
+		uses = append(uses, &modfile.Use{Path: "./" + s.AppName})
+	}
+	f.SetUse(uses)
+	if err := os.WriteFile(filepath.Join(target, "go.work"), modfile.Format(f.Syntax), 0644); err != nil {
+		fmt.Printf("--> Couldn't create go workspace, aborting.\n")
+		return err
+	}
+	fmt.Printf("--> Successfully created go workspace, continuing\n")
+	return nil
+}
+
+// Creates the README, Makefile and docker-compose.yaml of the monorepo
+func GenerateMonorepoFiles(target string, data MonorepoData) error {
+	for _, f := range []struct{ name, content string }{
+		{"README.md", MONOREPO_README_BASE},
+		{"Makefile", MONOREPO_MAKEFILE_BASE},
+		{"docker-compose.yaml", MONOREPO_DOCKER_COMPOSE_BASE},
+	} {
+		file, err := createFile(f.name, target)
+		if err != nil {
+			return err
+		}
+		if err := writeTemplate(file, f.content, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Creates CI pipelines testing every module and building
+// the image of every server for the configured providers
+func GenerateMonorepoCIFiles(target string, data MonorepoData) error {
+	for _, provider := range data.CI {
+		switch provider {
+		case "github":
+			if err := createDirectories(target, ".github/workflows"); err != nil {
+				return err
+			}
+			workflow, err := createFile(".github/workflows/ci.yaml", target)
+			if err != nil {
+				return err
+			}
+			if err := writeTemplate(workflow, MONOREPO_CI_GITHUB_BASE, data); err != nil {
+				return err
+			}
+		case "gitlab":
+			pipeline, err := createFile(".gitlab-ci.yml", target)
+			if err != nil {
+				return err
+			}
+			if err := writeTemplate(pipeline, MONOREPO_CI_GITLAB_BASE, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RouterBackend contains the code needed to route requests to the
+// handlers of the generated server with a particular router. Handlers
+// are plain http.HandlerFuncs reading path parameters through
+// r.PathValue regardless of the router, so routers which don't set
+// path values themselves are given an adapter which does
+type RouterBackend struct {
+	Name        string
+	Package     string // Module collected with go get, empty for the standard library
+	Constructor string // Code declaring the router variable
+	Attachment  string // Format of a line attaching a handler, given the method, path and handler
+	ColonParams bool   // Whether path parameters are written as :name rather than {name}
+	Adapter     string // Code of the withPathValues adapter, if needed
+}
+
+// Routers which the generated server can be built with
+var routerBackends = map[string]RouterBackend{
+	"stdlib": {
+		Name:        "stdlib",
+		Constructor: "router := http.NewServeMux()",
+		Attachment:  `router.HandleFunc("%[1]s %[2]s", %[3]s)`,
+	},
+	"httprouter": {
+		Name:        "httprouter",
+		Package:     "github.com/julienschmidt/httprouter",
+		Constructor: "router := httprouter.New()",
+		Attachment:  `router.HandlerFunc(%[4]s, "%[2]s", withPathValues(%[3]s))`,
+		ColonParams: true,
+		Adapter: `// withPathValues exposes the parameters matched by the router
+// through r.PathValue, as with http.ServeMux
+func withPathValues(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, p := range httprouter.ParamsFromContext(r.Context()) {
+			r.SetPathValue(p.Key, p.Value)
+		}
+		next(w, r)
+	}
+}`,
+	},
+	"chi": {
+		Name:        "chi",
+		Package:     "github.com/go-chi/chi/v5",
+		Constructor: "router := chi.NewRouter()",
+		Attachment:  `router.MethodFunc(%[4]s, "%[2]s", %[3]s)`,
+	},
+	"gorilla": {
+		Name:        "gorilla",
+		Package:     "github.com/gorilla/mux",
+		Constructor: "router := mux.NewRouter()",
+		Attachment:  `router.HandleFunc("%[2]s", withPathValues(%[3]s)).Methods(%[4]s)`,
+		Adapter: `// withPathValues exposes the parameters matched by the router
+// through r.PathValue, as with http.ServeMux
+func withPathValues(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range mux.Vars(r) {
+			r.SetPathValue(k, v)
+		}
+		next(w, r)
+	}
+}`,
+	},
+	"echo": {
+		Name:        "echo",
+		Package:     "github.com/labstack/echo/v4",
+		Constructor: "router := echo.New()",
+		Attachment:  `router.Add(%[4]s, "%[2]s", withPathValues(%[3]s))`,
+		ColonParams: true,
+		Adapter: `// withPathValues adapts a handler to the router, exposing
+// the parameters it matched through r.PathValue
+func withPathValues(next http.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		r := c.Request()
+		for i, name := range c.ParamNames() {
+			r.SetPathValue(name, c.ParamValues()[i])
+		}
+		next(c.Response(), r)
+		return nil
+	}
+}`,
+	},
+	"gin": {
+		Name:    "gin",
+		Package: "github.com/gin-gonic/gin",
+		Constructor: `gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.HandleMethodNotAllowed = true`,
+		Attachment:  `router.Handle(%[4]s, "%[2]s", withPathValues(%[3]s))`,
+		ColonParams: true,
+		Adapter: `// withPathValues adapts a handler to the router, exposing
+// the parameters it matched through r.PathValue
+func withPathValues(next http.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, p := range c.Params {
+			c.Request.SetPathValue(p.Key, p.Value)
+		}
+		next(c.Writer, c.Request)
+	}
+}`,
+	},
+}
+
+// Constants of the HTTP methods endpoints can be defined with
+var httpMethods = map[string]string{
+	"GET":     "http.MethodGet",
+	"HEAD":    "http.MethodHead",
+	"POST":    "http.MethodPost",
+	"PUT":     "http.MethodPut",
+	"PATCH":   "http.MethodPatch",
+	"DELETE":  "http.MethodDelete",
+	"OPTIONS": "http.MethodOptions",
+}
+
+// Endpoints served by every generated server
+var builtinEndpoints = []EndpointDefinition{
+	{Path: "/v1/healthcheck", Method: "GET", Description: "Displays server status and build information", handler: "healthcheckHandler"},
+	{Path: "/v1/readycheck", Method: "GET", Description: "Runs dependency checks, fails while shutting down", handler: "readycheckHandler"},
+}
+
+// Returns a templated line of code attaching the handler of
+// the given endpoint to its path via the router
+func (r RouterBackend) Attach(e EndpointDefinition) string {
+	path := e.Path
+	if r.ColonParams {
+		path = e.colonPath()
+	}
+	return fmt.Sprintf(r.Attachment, e.Method, path, "a."+e.HandlerName(), httpMethods[e.Method])
+}
+
+// Returns the names of the supported routers
+func routerNames() []string {
+	names := make([]string, 0, len(routerBackends))
+	for name := range routerBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Checks that the generated server can be built with the given router
+func validateRouter(router string) error {
+	if _, ok := routerBackends[router]; !ok {
+		return fmt.Errorf("unsupported router %q, must be one of %s", router, strings.Join(routerNames(), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Returns the configuration as loaded, with every default set, leaving
+// out the settings of other deploy targets, which have no effect
+func effectiveYamlConfig(conf *YamlConfig) *YamlConfig {
+	yamlConf := *conf
+	if yamlConf.Deploy != "kubernetes" && yamlConf.Deploy != "helm" {
+		yamlConf.Kubernetes = KubernetesDefinition{}
+	}
+	if yamlConf.Deploy != "systemd" {
+		yamlConf.Systemd = SystemdDefinition{}
+	}
+	return &yamlConf
+}
+
+// Writes the effective configuration to a configuration file, from
+// which the same server can be generated with talbot make -c
+func saveConfig(filename string, conf *YamlConfig) error {
+	var doc yaml.Node
+	if err := doc.Encode(effectiveYamlConfig(conf)); err != nil {
+		return err
+	}
+	// Leave out unset values, which are the defaults
+	pruneEmptyNodes(&doc)
+	escapeDollarSigns(&doc)
+	doc.HeadComment = fmt.Sprintf("Configuration of the %s server, saved by talbot make\nGenerate the server again with: talbot make -c %s", conf.AppName, filename)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	dir, name := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	file, err := createFile(name, dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(buf.Bytes())
+	return err
+}
+
+// Removes values from mappings which are empty, false or zero, returning
+// whether the node itself is empty. Items of lists and variables of
+// environments, which are set even if empty, are always kept
+func pruneEmptyNodes(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.MappingNode:
+		content := n.Content[:0]
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == "environment" && n.Content[i+1].Kind == yaml.MappingNode {
+				if len(n.Content[i+1].Content) > 0 {
+					content = append(content, n.Content[i], n.Content[i+1])
+				}
+				continue
+			}
+			if !pruneEmptyNodes(n.Content[i+1]) {
+				content = append(content, n.Content[i], n.Content[i+1])
+			}
+		}
+		n.Content = content
+		return len(n.Content) == 0
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			pruneEmptyNodes(c)
+		}
+		return len(n.Content) == 0
+	case yaml.ScalarNode:
+		switch n.ShortTag() {
+		case "!!null":
+			return true
+		case "!!str":
+			return n.Value == ""
+		case "!!bool":
+			return n.Value == "false"
+		case "!!int":
+			return n.Value == "0"
+		}
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			pruneEmptyNodes(c)
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestPruneEmptyNodes checks that unset values are left out of
+// mappings, while items of lists are kept
+func TestPruneEmptyNodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{"unset values", "a: \"\"\nb: false\nc: 0\nd: null\ne: x\n", "e: x\n"},
+		{"set values", "a: \"0\"\nb: true\nc: 1\nd: 0.0\n", "a: \"0\"\nb: true\nc: 1\nd: 0.0\n"},
+		{"empty mappings", "a:\n    b: \"\"\n    c:\n        d: 0\ne: x\n", "e: x\n"},
+		{"empty lists", "a: []\nb:\n    - x\n", "b:\n    - x\n"},
+		{"list items", "a:\n    - \"\"\n    - 0\n    - b: \"\"\n      c: x\n    - b: \"\"\n", "a:\n    - \"\"\n    - 0\n    - c: x\n    - {}\n"},
+		{"environment variables", "container:\n    environment:\n        A: \"\"\n    services:\n        - name: x\n          environment: {}\n", "container:\n    environment:\n        A: \"\"\n    services:\n        - name: x\n"},
+		{"everything unset", "a:\n    b: false\n", "{}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.yaml), &doc); err != nil {
+				t.Fatal(err)
+			}
+			pruneEmptyNodes(&doc)
+			out, err := yaml.Marshal(&doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, out)
+			}
+		})
+	}
+}
+
+// TestSaveConfig checks that a saved configuration is loaded
+// into the same configuration it was saved from
+func TestSaveConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"defaults", "appName: svc\n"},
+		{"endpoints and settings", "appName: svc\nrouter: gin\nport: 8080\nendpoints:\n  - path: /v1/users/{id}\n    method: delete\n    description: Deletes a user\nhealthChecks:\n  - name: tmp\n    type: disk\n    target: /tmp\nconfig:\n  - name: verbose\n    type: bool\n    default: T\n  - name: maxWorkers\n    type: int\n    default: 0\n"},
+		{"helm", "appName: svc\ndeploy: helm\nkubernetes:\n  replicas: 3\n  ingress:\n    host: svc.example.com\nsystemd:\n  user: svc\n"},
+		{"systemd", "appName: svc\ndeploy: systemd\nkubernetes:\n  replicas: 3\nsystemd:\n  user: svc\n"},
+		{"container", "appName: svc\ncontainer:\n  profile: alpine\n  environment:\n    EMPTY: \"\"\n  services:\n    - name: postgres\n      image: postgres:16-alpine\n"},
+		{"no ci", "appName: svc\nci: none\ngit:\n  init: true\n  author: Jane Doe <jane@example.com>\nmodules:\n  offline: true\n"},
+		{"preset", "appName: svc\nextends: full\n"},
+		{"dollar signs", "appName: svc\nendpoints:\n  - path: /v1/prices\n    description: Costs $$5, or $${PRICE} if set\ncontainer:\n  environment:\n    PRICE: \"$$$$\"\n    $KEY: kept\n"},
+		{"monorepo", "appName: shop\nmodName: example.com/shop\nservices:\n  - name: billing\n  - name: orders\n    router: chi\n    port: 5000\n"},
+		{"platform", "appName: svc\nplatform:\n  module: example.com/platform\n  version: v1.2.0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := loadConfig(writeConfig(t, "talbot.yaml", tt.config), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			saved := filepath.Join(t.TempDir(), "saved.yaml")
+			if err := saveConfig(saved, conf); err != nil {
+				t.Fatal(err)
+			}
+			reloaded, err := loadConfig(saved, nil)
+			if err != nil {
+				t.Fatalf("Expected saved configuration to be valid, got %q.", err)
+			}
+			if expected, got := effectiveYamlConfig(conf), effectiveYamlConfig(reloaded); !reflect.DeepEqual(expected, got) {
+				t.Errorf("Expected %+v, got %+v.", expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// jsonSchema is the subset of JSON Schema used to describe
+// configuration files, which editors use for autocomplete
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 schemaTypes            `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"` // false, or the schema of map values
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	MaxLength            int                    `json:"maxLength,omitempty"`
+	Maximum              int                    `json:"maximum,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+}
+
+// schemaTypes lists the JSON types a value may have
+type schemaTypes []string
+
+// Writes a single type as a string, as is conventional
+func (t schemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Returns whether the value may have the given JSON type
+func (t schemaTypes) allows(typ string) bool {
+	for _, allowed := range t {
+		// Integers are numbers too
+		if allowed == typ || (allowed == "number" && typ == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the schema of the value at path, (i.e. endpoints[].method)
+func (s *jsonSchema) at(path string) *jsonSchema {
+	for _, field := range strings.Split(path, ".") {
+		items := strings.Count(field, "[]")
+		field = strings.TrimSuffix(field, strings.Repeat("[]", items))
+		if s = s.Properties[field]; s == nil {
+			return nil
+		}
+		for ; items > 0 && s != nil; items-- {
+			s = s.Items
+		}
+	}
+	return s
+}
+
+// Returns the schema of the values the Go type is decoded from,
+// describing structs by the json tags of their fields
+func schemaFor(t reflect.Type) *jsonSchema {
+	switch {
+	case t == reflect.TypeOf(ScalarString("")):
+		return &jsonSchema{Type: schemaTypes{"string", "number", "boolean"}}
+	case t == reflect.TypeOf(StringList{}):
+		return &jsonSchema{Type: schemaTypes{"string", "array"}, Items: &jsonSchema{Type: schemaTypes{"string"}}}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &jsonSchema{Type: schemaTypes{"object"}, Properties: map[string]*jsonSchema{}, AdditionalProperties: false}
+		for i := 0; i < t.NumField(); i++ {
+			if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+				s.Properties[name] = schemaFor(t.Field(i).Type)
+			}
+		}
+		return s
+	case reflect.Map:
+		return &jsonSchema{Type: schemaTypes{"object"}, AdditionalProperties: schemaFor(t.Elem())}
+	case reflect.Slice:
+		return &jsonSchema{Type: schemaTypes{"array"}, Items: schemaFor(t.Elem())}
+	case reflect.Bool:
+		return &jsonSchema{Type: schemaTypes{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: schemaTypes{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: schemaTypes{"number"}}
+	}
+	return &jsonSchema{Type: schemaTypes{"string"}}
+}
+
+// Returns descriptions, allowed values and defaults of the settings of
+// configuration files by path. Allowed values are taken from the lists
+// the settings are validated against, so that they can't drift apart
+func configSchemaAnnotations() map[string]jsonSchema {
+	methods := make([]string, 0, len(httpMethods))
+	for m := range httpMethods {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	fieldTypes := make([]string, 0, len(configFieldTypes))
+	for t := range configFieldTypes {
+		fieldTypes = append(fieldTypes, t)
+	}
+	sort.Strings(fieldTypes)
+	return map[string]jsonSchema{
+		"extends":                         {Description: "Configuration files, relative to this one, or presets (" + strings.Join(presetNames(), ", ") + ") whose settings this configuration starts from, with later ones taking precedence"},
+		"appName":                         {Description: "Name of the application, used for its directory, binary, image and deployment resources", Pattern: appNameRegex.String(), MaxLength: 63},
+		"directory":                       {Description: "Directory the application directory is created in", Default: "./"},
+		"modName":                         {Description: "Path of the go module of the application, (default $appName)"},
+		"router":                          {Description: "Router requests are routed with", Enum: routerNames(), Default: "httprouter"},
+		"endpoints":                       {Description: "Custom endpoints served alongside the built-in endpoints, generated with a stub handler"},
+		"endpoints[].path":                {Description: "Path with {name} parameters, (i.e. /v1/users/{id})"},
+		"endpoints[].method":              {Description: "HTTP method, one of " + strings.Join(methods, ", "), Default: "GET"},
+		"endpoints[].description":         {Description: "Description of the endpoint listed in the README"},
+		"healthChecks":                    {Description: "Dependency checks run by the readiness endpoint"},
+		"healthChecks[].name":             {Description: "Name of the dependency reported by the readiness endpoint"},
+		"healthChecks[].type":             {Description: "Whether to request a URL or check that a directory is writable", Enum: []string{"http", "disk"}},
+		"healthChecks[].target":           {Description: "URL for http checks, directory for disk checks"},
+		"healthChecks[].timeout":          {Description: "Duration after which the check fails, (i.e. 500ms, 2s)", Default: defaultHealthCheckTimeout.String()},
+		"config":                          {Description: "Custom settings loaded by the generated server from defaults, a config file, environment variables and flags"},
+		"config[].name":                   {Description: "camelCase name of the setting", Pattern: configNameRegex.String()},
+		"config[].type":                   {Description: "Go type the setting is parsed into", Enum: fieldTypes},
+		"config[].default":                {Description: "Value used when the setting isn't set"},
+		"config[].env":                    {Description: "Environment variable the setting is read from, (default $NAME in SCREAMING_SNAKE_CASE)"},
+		"config[].secret":                 {Description: "Whether the value is redacted and kept out of generated files"},
+		"config[].required":               {Description: "Whether the server refuses to start without a value"},
+		"config[].description":            {Description: "Description of the setting listed in the README and flag usage"},
+		"port":                            {Description: "Port the generated server listens on", Maximum: 65535, Default: defaultPort},
+		"container":                       {Description: "Container image and docker-compose environment of the generated server"},
+		"container.profile":               {Description: "Runtime image of the container", Enum: containerProfiles, Default: "scratch"},
+		"container.environment":           {Description: "Environment variables set in docker-compose, overriding the defaults of settings"},
+		"container.services":              {Description: "Services the generated server depends on, run by docker-compose"},
+		"container.services[].name":       {Description: "Name of the service, which is also its hostname"},
+		"container.services[].image":      {Description: "Image the service is run from"},
+		"container.services[].ports":      {Description: "host:container port mappings"},
+		"deploy":                          {Description: "Target to generate deployment files in remote for", Enum: deployTargets, Default: "kubernetes"},
+		"kubernetes":                      {Description: "Scheduling and scaling settings for kubernetes and helm deployments"},
+		"kubernetes.replicas":             {Default: 2},
+		"kubernetes.minReplicas":          {Description: "Minimum replicas of the autoscaler, (default $replicas)"},
+		"kubernetes.maxReplicas":          {Description: "Maximum replicas of the autoscaler, (default $minReplicas + 3)"},
+		"kubernetes.targetCPUUtilization": {Description: "Percentage of requested CPU the autoscaler targets", Maximum: 100, Default: 80},
+		"kubernetes.requests.cpu":         {Default: "100m"},
+		"kubernetes.requests.memory":      {Default: "64Mi"},
+		"kubernetes.limits.cpu":           {Default: "500m"},
+		"kubernetes.limits.memory":        {Default: "128Mi"},
+		"kubernetes.ingress":              {Description: "How the server is exposed outside of the cluster, disabled unless a host is set"},
+		"kubernetes.ingress.tlsSecret":    {Description: "Secret containing the TLS certificate for host"},
+		"systemd":                         {Description: "Service user and host settings for systemd deployments"},
+		"systemd.user":                    {Description: "User the service runs as, (default $appName)"},
+		"systemd.host":                    {Description: "Domain served by the reverse proxy, (default any)"},
+		"ci":                              {Description: "Providers to generate CI pipelines for, or none", Default: []string{"github"}},
+		"ci[]":                            {Enum: append([]string{"none"}, ciProviders...)},
+		"git":                             {Description: "Git repository initialized in the generated project"},
+		"git.init":                        {Description: "Whether to initialize a git repository with an initial commit"},
+		"git.author":                      {Description: "Author of the initial commit, (i.e. Jane Doe <jane@example.com>)"},
+		"modules":                         {Description: "How the dependencies of the generated server are collected"},
+		"modules.go":                      {Description: "Go directive of the generated module, (default local go version)"},
+		"modules.toolchain":               {Description: "Toolchain line of the generated module, (i.e. go1.22.3)"},
+		"modules.offline":                 {Description: "Use pinned module versions rather than go get, without using the network"},
+		"modules.vendor":                  {Description: "Copy dependencies into vendor/"},
+		"modules.tidy":                    {Description: "Run go mod tidy once generated"},
+		"platform":                        {Description: "Module of logging, error response, health check and middleware code which generated servers import rather than each defining their own. Servers of a monorepo always import one, generated into internal/platform unless an existing module is set"},
+		"platform.generate":               {Description: "Whether to generate the module into internal/platform, which the server requires through a replace directive"},
+		"platform.module":                 {Description: "Path of an existing module to import, or of the generated module, (default $modName/platform)"},
+		"platform.version":                {Description: "Version of the existing module to import, (i.e. v1.2.0)", Default: "latest"},
+		"services":                        {Description: "Servers generated into a monorepo named appName, sharing a go.work, platform module, docker-compose and Makefile. Unset settings of servers are taken from the top-level settings"},
+		"services[].name":                 {Description: "Name of the server, used for its directory, binary, image and docker-compose service", Pattern: appNameRegex.String(), MaxLength: 63},
+		"services[].modName":              {Description: "Path of the go module of the server, (default $modName/$name)"},
+		"services[].port":                 {Description: "Port the server listens on, (default $port plus the index of the server)", Maximum: 65535},
+	}
+}
+
+// Returns the JSON Schema of configuration files
+func configSchema() *jsonSchema {
+	s := schemaFor(reflect.TypeOf(YamlConfig{}))
+	s.Schema = "http://json-schema.org/draft-07/schema#"
+	s.Title = "talbot configuration"
+	s.Description = "Configuration of a server generated by talbot make"
+	s.Required = []string{"appName"}
+	for path, required := range map[string][]string{
+		"endpoints[]":          {"path"},
+		"healthChecks[]":       {"name", "type", "target"},
+		"config[]":             {"name", "type"},
+		"container.services[]": {"name", "image"},
+		"services[]":           {"name"},
+	} {
+		s.at(path).Required = required
+	}
+	// JSON files name their schema in the file itself, as editors expect
+	s.Properties["$schema"] = &jsonSchema{Type: schemaTypes{"string"}, Description: "JSON Schema editors check the file against, which talbot ignores"}
+	annotations := configSchemaAnnotations()
+	annotate := func(path string) {
+		a, field := annotations[path], s.at(path)
+		if field == nil {
+			panic("no setting at schema annotation path " + path)
+		}
+		field.Description = a.Description
+		field.Enum = a.Enum
+		field.Pattern = a.Pattern
+		field.MaxLength = a.MaxLength
+		field.Maximum = a.Maximum
+		field.Default = a.Default
+	}
+	for path := range annotations {
+		if !strings.HasPrefix(path, "services") {
+			annotate(path)
+		}
+	}
+	// Settings of servers are described like the top-level settings
+	// they default to, which their own annotations then override
+	servers := s.at("services[]")
+	for name := range servers.Properties {
+		if top, ok := s.Properties[name]; ok {
+			inherited := *top
+			inherited.Default = nil
+			servers.Properties[name] = &inherited
+		}
+	}
+	for path := range annotations {
+		if strings.HasPrefix(path, "services") {
+			annotate(path)
+		}
+	}
+	return s
+}
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Prints the JSON Schema of configuration files",
+	Long:  "Prints the JSON Schema of configuration files, which editors can use to complete and check them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(configSchema())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
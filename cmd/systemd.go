@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+)
+
+var SYSTEMD_UNIT_BASE = `[Unit]
+Description={{.AppName}} server
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+User={{.Systemd.User}}
+Group={{.Systemd.User}}
+EnvironmentFile=/etc/{{.AppName}}/{{.AppName}}.env
+ExecStart=/usr/local/bin/{{.AppName}}
+Restart=on-failure
+RestartSec=5s
+# Leave time for the server to fail readiness and drain in-flight requests
+KillSignal=SIGTERM
+TimeoutStopSec=30s
+
+# Sandbox the server, which only needs to listen on its port
+NoNewPrivileges=true
+ProtectSystem=strict
+ProtectHome=true
+PrivateTmp=true
+PrivateDevices=true
+ProtectClock=true
+ProtectHostname=true
+ProtectKernelTunables=true
+ProtectKernelModules=true
+ProtectKernelLogs=true
+ProtectControlGroups=true
+RestrictAddressFamilies=AF_INET AF_INET6 AF_UNIX
+RestrictNamespaces=true
+RestrictRealtime=true
+RestrictSUIDSGID=true
+LockPersonality=true
+MemoryDenyWriteExecute=true
+SystemCallArchitectures=native
+SystemCallFilter=@system-service
+SystemCallFilter=~@privileged @resources
+{{- if lt .Port 1024}}
+# Allow binding to a privileged port without running as root
+CapabilityBoundingSet=CAP_NET_BIND_SERVICE
+AmbientCapabilities=CAP_NET_BIND_SERVICE
+{{- else}}
+CapabilityBoundingSet=
+AmbientCapabilities=
+{{- end}}
+UMask=0077
+
+[Install]
+WantedBy=multi-user.target
+`
+
+var SYSTEMD_ENV_BASE = `# Settings for the {{.AppName}} server, installed to /etc/{{.AppName}}/{{.AppName}}.env
+# Fill in secret and required settings on the host and never commit them
+{{- range .ConfigFields}}
+
+{{.GenerateEnvFileEntry}}
+{{- end}}
+`
+
+var CADDYFILE_BASE = `# Reverse proxy for the {{.AppName}} server, Caddy provisions TLS automatically when a domain is set
+{{if .Systemd.Host}}{{.Systemd.Host}}{{else}}:80{{end}} {
+	reverse_proxy localhost:{{.Port}} {
+		health_uri /v1/readycheck
+	}
+}
+`
+
+var NGINX_CONF_BASE = `# Reverse proxy for the {{.AppName}} server, install to /etc/nginx/conf.d/{{.AppName}}.conf
+upstream {{.AppName}} {
+    server 127.0.0.1:{{.Port}};
+    keepalive 16;
+}
+
+server {
+    listen 80;
+    server_name {{if .Systemd.Host}}{{.Systemd.Host}}{{else}}_{{end}};
+
+    location / {
+        proxy_pass http://{{.AppName}};
+        proxy_http_version 1.1;
+        proxy_set_header Connection "";
+        proxy_set_header Host $host;
+        proxy_set_header X-Real-IP $remote_addr;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_set_header X-Forwarded-Proto $scheme;
+    }
+}
+`
+
+var SETUP_SCRIPT_BASE = `#!/bin/bash
+
+# ----------------------------------------------------------------------------
+# setup.sh
+# - Installs the {{.AppName}} server as a systemd service, run as root
+#   after building the server into bin/ with make build
+# ----------------------------------------------------------------------------
+# Fail on errors
+set -euo pipefail
+
+APP="{{.AppName}}"
+SERVICE_USER="{{.Systemd.User}}"
+
+# Run from the project root
+cd "$(dirname "$0")/../.."
+
+if [ ! -f "bin/${APP}" ]; then
+    echo "bin/${APP} not found, run make build first" >&2
+    exit 1
+fi
+
+# Create a system user without a login shell or home directory
+if ! id -u "${SERVICE_USER}" >/dev/null 2>&1; then
+    useradd --system --no-create-home --shell /usr/sbin/nologin "${SERVICE_USER}"
+fi
+
+# Install the binary and unit
+install -m 0755 "bin/${APP}" "/usr/local/bin/${APP}"
+install -m 0644 "remote/systemd/${APP}.service" "/etc/systemd/system/${APP}.service"
+systemctl daemon-reload
+systemctl enable "${APP}"
+
+# Keep existing settings when re-run, only starting once they have been filled in
+install -d -m 0750 -o root -g "${SERVICE_USER}" "/etc/${APP}"
+if [ ! -f "/etc/${APP}/${APP}.env" ]; then
+    install -m 0640 -o root -g "${SERVICE_USER}" "remote/systemd/${APP}.env" "/etc/${APP}/${APP}.env"
+    echo "Fill in /etc/${APP}/${APP}.env, then run: systemctl start ${APP}"
+    exit 0
+fi
+
+systemctl restart "${APP}"
+echo "Restarted ${APP}, check its status with: systemctl status ${APP}"
+`
+
+// Creates a systemd unit, environment file, reverse proxy
+// configurations and a provisioning script
+func GenerateSystemdBundle(target string, data TemplateData) error {
+	systemd := filepath.Join(target, "remote", "systemd")
+	if err := createDirectories(filepath.Join(target, "remote"), "systemd"); err != nil {
+		return err
+	}
+	files := [][]string{
+		{data.AppName + ".service", SYSTEMD_UNIT_BASE},
+		{data.AppName + ".env", SYSTEMD_ENV_BASE},
+		{"Caddyfile", CADDYFILE_BASE},
+		{"nginx.conf", NGINX_CONF_BASE},
+		{"setup.sh", SETUP_SCRIPT_BASE},
+	}
+	for _, f := range files {
+		file, err := createFile(f[0], systemd)
+		if err != nil {
+			return err
+		}
+		if err := writeTemplate(file, f[1], data); err != nil {
+			return err
+		}
+	}
+	// Allow the provisioning script to be run directly
+	return os.Chmod(filepath.Join(systemd, "setup.sh"), 0755)
+}
@@ -1,43 +1,101 @@
 package cmd
 
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
 var MAIN_BASE = `package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 	"time"
-
-	"github.com/julienschmidt/httprouter"
+{{- if or .Router.Package .Platform}}
+{{end}}
+{{- with .Router.Package}}
+	"{{.}}"
+{{- end}}
+{{- with .Platform}}
+	"{{.}}"
+{{- end}}
 )
 
-// Struct encapsulating all configuration settings for application
-type config struct {
-	port int // Network port that we want server to listen on
-}
+// Build information reported by the healthcheck endpoints, set at compile
+// time through -ldflags (i.e. -X main.version=v1.0.0)
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildTime = "unknown"
+)
 
 // Struct encapsulating all dependancies for HTTP handlers, helpers and middleware
 // Should also contain any variables pertaining to application state that needs
 // to be accessible to any handlers
 type application struct {
-	config config // Configuration settings for application
+	config       config          // Configuration settings for application
+	logger       *log.Logger     // Logger to control messaging to stdout stream
+	health       *healthRegistry // Dependency checks run by the readiness endpoint
+	shuttingDown atomic.Bool     // Set once graceful shutdown has started
 }
 
 func main() {
 	var cfg config // Application configuration settings
 
-	// Parse port and operating environment from given flags
-	flag.IntVar(&cfg.port, "port", 4000, "API server port")
-	flag.Parse()
-
 	// Logger to control messaging to stdout stream
+{{- if .Platform}}
+	logger := platform.NewLogger("{{.AppName}}")
+{{- else}}
 	logger := log.New(os.Stdout, "", log.Ldate|log.Ltime)
+{{- end}}
+
+	// Register configuration settings alongside operational flags
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fields := cfg.bind(fs)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "Path to an optional JSON configuration file")
+	displayVersion := fs.Bool("version", false, "Display build information and exit")
+	displayConfig := fs.Bool("print-config", false, "Display the loaded configuration with secrets redacted and exit")
+	runHealthcheck := fs.Bool("healthcheck", false, "Query the liveness endpoint of a running server and exit")
+	fs.Parse(os.Args[1:])
+
+	if *displayVersion {
+		fmt.Printf("version:\t%s\ncommit:\t\t%s\nbuild time:\t%s\n", version, commit, buildTime)
+		os.Exit(0)
+	}
+
+	// Merge settings from the config file and environment with the parsed flags
+	if err := loadConfig(fs, fields, *configFile, os.Getenv); err != nil {
+		logger.Fatal(err)
+	}
+	if *displayConfig {
+		printConfig(os.Stdout, fs, fields)
+		os.Exit(0)
+	}
+	if *runHealthcheck {
+		if err := probeLiveness(cfg.port); err != nil {
+			logger.Fatal(err)
+		}
+		os.Exit(0)
+	}
+	if err := validateConfig(fields); err != nil {
+		logger.Fatal(err)
+	}
 
 	app := &application{
 		config: cfg,
+		logger: logger,
+		health: newHealthRegistry(),
 	}
+	app.registerHealthChecks()
 
 	// HTTP server with basic sensible timeout settings
 	srv := &http.Server{
@@ -50,47 +108,622 @@ func main() {
 
 	// Start HTTP Server
 	logger.Printf("starting server on %s", srv.Addr)
+	if err := app.serve(srv); err != nil {
+		logger.Fatal(err)
+	}
+	logger.Printf("stopped server on %s", srv.Addr)
+}
+
+// serve starts the HTTP server and blocks until it has been shut down
+// gracefully after receiving a SIGINT or SIGTERM signal
+func (a *application) serve(srv *http.Server) error {
+	shutdownError := make(chan error)
+
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		s := <-quit
+		a.logger.Printf("caught signal %s, shutting down server", s)
+		// Fail the readiness endpoint first so that load balancers stop
+		// sending new traffic before in-flight requests are drained
+		a.shuttingDown.Store(true)
+		time.Sleep(a.config.shutdownDelay)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		shutdownError <- srv.Shutdown(ctx)
+	}()
+
 	err := srv.ListenAndServe()
-	logger.Fatal(err)
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-shutdownError
 }
 
-func (a *application) routes() *httprouter.Router {
+// routes attaches the endpoint handlers to a router wrapped in middleware
+func (a *application) routes() http.Handler {
 	// Create a new HTTP router
-	router := httprouter.New()
+	{{.Router.Constructor}}
 	// Attach endpoint handler methods
-	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", a.healthcheckHandler)
+{{- range .Endpoints}}
+	{{$.Router.Attach .}}
+{{- end}}
 	// Return the configured router
-	return router
+	return a.recoverPanic(router)
+}
+{{- with .Router.Adapter}}
+
+{{.}}
+{{- end}}
+`
+
+var MIDDLEWARE_BASE = `package main
+
+import (
+{{- if .Platform}}
+	"net/http"
+
+	"{{.Platform}}"
+{{- else}}
+	"fmt"
+	"net/http"
+{{- end}}
+)
+
+// recoverPanic replies with a 500 status rather than dropping
+// the connection when a handler panics
+func (a *application) recoverPanic(next http.Handler) http.Handler {
+{{- if .Platform}}
+	return platform.RecoverPanic(a.logger, next)
+{{- else}}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				w.Header().Set("Connection", "close")
+				a.logger.Print(fmt.Errorf("recovered from panic: %v", err))
+				replyTextContent(w, r, http.StatusInternalServerError, "the server encountered a problem")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+{{- end}}
 }
 `
 
 var HANDERS_BASE = `package main
 
 import (
+{{- if .Platform}}
+	"net/http"
+
+	"{{.Platform}}"
+{{- else}}
+	"encoding/json"
 	"net/http"
+{{- end}}
 )
+{{- if .Platform}}
+
+// Responses are written by the platform module shared with other servers
+type envelope = platform.Envelope
+
+var (
+	replyTextContent = platform.ReplyText
+	replyJSONContent = platform.ReplyJSON
+)
+{{- else}}
+
+// envelope wraps JSON responses in a top-level object
+type envelope map[string]any
+{{- end}}
 
-// Replies with a 200 status indicating server is up and running
+// Liveness probe, replies with a 200 status and build information
+// whenever the server is able to handle requests
 func (a *application) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
-	replyTextContent(w, r, http.StatusOK, "OK")
+	replyJSONContent(w, r, http.StatusOK, envelope{
+		"status":      "available",
+		"system_info": buildInfo(),
+	})
 }
 
+// Readiness probe, runs all registered dependency checks and replies with
+// a 503 status if any of them fail or the server is shutting down
+func (a *application) readycheckHandler(w http.ResponseWriter, r *http.Request) {
+	if a.shuttingDown.Load() {
+		replyJSONContent(w, r, http.StatusServiceUnavailable, envelope{
+			"status":      "shutting down",
+			"system_info": buildInfo(),
+		})
+		return
+	}
+	checks, ok := a.health.Run(r.Context())
+	status, code := "ready", http.StatusOK
+	if !ok {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	replyJSONContent(w, r, code, envelope{
+		"status":      status,
+		"checks":      checks,
+		"system_info": buildInfo(),
+	})
+}
+{{- if not .Platform}}
+
 // replyTextContent wraps text content in a HTTP response and sends it
 func replyTextContent(w http.ResponseWriter, r *http.Request, status int, content string) {
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(status)
 	w.Write([]byte(content + "\n"))
 }
+
+// replyJSONContent encodes data as JSON in a HTTP response and sends it
+func replyJSONContent(w http.ResponseWriter, r *http.Request, status int, data any) {
+	js, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		replyTextContent(w, r, http.StatusInternalServerError, "couldn't encode response")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(js, '\n'))
+}
+{{- end}}
+{{- range .Endpoints}}
+{{.GenerateHandlerFunction}}
+{{- end}}
+`
+
+var HEALTH_BASE = `package main
+
+import (
+{{- if .Platform}}
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"{{.Platform}}"
+{{- else}}
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+{{- end}}
+)
+{{- if .Platform}}
+
+// Dependency checks are run by the platform module shared with other servers
+type (
+	checkerFunc    = platform.CheckerFunc
+	healthRegistry = platform.HealthRegistry
+)
+
+var (
+	newHealthRegistry = platform.NewHealthRegistry
+	databaseChecker   = platform.DatabaseChecker
+	httpChecker       = platform.HTTPChecker
+	diskChecker       = platform.DiskChecker
+)
+{{- else}}
+
+// checkerFunc reports whether a single dependency is healthy
+type checkerFunc func(ctx context.Context) error
+
+// healthCheck is a named dependency check with its own timeout
+type healthCheck struct {
+	name    string
+	timeout time.Duration
+	check   checkerFunc
+}
+
+// checkResult describes the outcome of a single dependency check
+type checkResult struct {
+	Status   string ` + "`" + `json:"status"` + "`" + `
+	Error    string ` + "`" + `json:"error,omitempty"` + "`" + `
+	Duration string ` + "`" + `json:"duration"` + "`" + `
+}
+
+// healthRegistry contains the dependency checks run by the readiness endpoint
+type healthRegistry struct {
+	mu     sync.RWMutex
+	checks []healthCheck
+}
+
+// newHealthRegistry returns an empty health registry
+func newHealthRegistry() *healthRegistry {
+	return &healthRegistry{}
+}
+
+// Register adds a dependency check to the registry
+func (h *healthRegistry) Register(name string, timeout time.Duration, check checkerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, healthCheck{name: name, timeout: timeout, check: check})
+}
+
+// Run executes all registered checks concurrently and returns the result
+// of each check along with whether all of them passed
+func (h *healthRegistry) Run(ctx context.Context) (map[string]checkResult, bool) {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]checkResult, len(checks))
+	healthy := true
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c healthCheck) {
+			defer wg.Done()
+			start := time.Now()
+			err := runWithTimeout(ctx, c.timeout, c.check)
+			res := checkResult{Status: "pass", Duration: time.Since(start).String()}
+			if err != nil {
+				res.Status, res.Error = "fail", err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[c.name] = res
+			healthy = healthy && err == nil
+		}(c)
+	}
+	wg.Wait()
+	return results, healthy
+}
+
+// runWithTimeout runs a check, giving up once the timeout has
+// elapsed even if the check does not respect its context
+func runWithTimeout(ctx context.Context, timeout time.Duration, check checkerFunc) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+{{- end}}
+
+// registerHealthChecks attaches the dependency checks run by the readiness endpoint
+func (a *application) registerHealthChecks() {
+{{- range .HealthChecks}}
+	{{.GenerateRegistration}}
+{{- end}}
+}
+
+// buildInfo returns version information about the running binary
+func buildInfo() map[string]string {
+	return map[string]string{
+		"version":    version,
+		"commit":     commit,
+		"build_time": buildTime,
+		"go_version": runtime.Version(),
+	}
+}
+
+// probeLiveness queries the liveness endpoint of a server listening on the
+// given port, allowing container healthchecks to run without a shell
+func probeLiveness(port int) error {
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://localhost:%d/v1/healthcheck", port))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("liveness endpoint replied with status %d", resp.StatusCode)
+	}
+	return nil
+}
+{{- if not .Platform}}
+
+// pinger is satisfied by *sql.DB and most database clients
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// databaseChecker checks that a database connection is alive
+func databaseChecker(db pinger) checkerFunc {
+	return db.PingContext
+}
+
+// httpChecker checks that a downstream HTTP dependency replies with a 2xx status
+func httpChecker(url string) checkerFunc {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+		}
+		return nil
+	}
+}
+
+// diskChecker checks that a directory exists and is writable
+func diskChecker(dir string) checkerFunc {
+	return func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		return os.Remove(f.Name())
+	}
+}
+{{- end}}
+`
+
+var CONFIG_BASE = `package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Struct encapsulating all configuration settings for application
+type config struct {
+{{- range .ConfigFields}}
+	{{.GenerateStructField}}
+{{- end}}
+}
+
+// configField describes where a single setting can be loaded from
+type configField struct {
+	name     string // Name of the command line flag and config file key
+	env      string // Environment variable the setting can be loaded from
+	secret   bool   // Whether the value is redacted when printing configuration
+	required bool   // Whether startup fails if the setting isn't provided
+	source   string // Where the value was loaded from (default, file, env or flag)
+}
+
+// bind registers every setting as a command line flag holding its
+// default value, returning the settings to load from other sources
+func (cfg *config) bind(fs *flag.FlagSet) []*configField {
+{{- range .ConfigFields}}
+	{{.GenerateFlagBinding}}
+{{- end}}
+	return []*configField{
+{{- range .ConfigFields}}
+		{{.GenerateFieldEntry}}
+{{- end}}
+	}
+}
+
+// loadConfig merges settings from an optional JSON config file and environment
+// variables into an already parsed flag set. Flags take precedence over environment
+// variables, which take precedence over the config file, which takes precedence
+// over built-in defaults
+func loadConfig(fs *flag.FlagSet, fields []*configField, configFile string, getenv func(string) string) error {
+	fromFlags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		fromFlags[f.Name] = true
+	})
+
+	fromFile := map[string]string{}
+	if configFile != "" {
+		var err error
+		if fromFile, err = readConfigFile(configFile); err != nil {
+			return err
+		}
+	}
+
+	known := map[string]bool{}
+	for _, f := range fields {
+		known[f.name] = true
+		f.source = "default"
+		var value string
+		if fromFlags[f.name] {
+			f.source = "flag"
+			continue
+		} else if v := getenv(f.env); v != "" {
+			value, f.source = v, "env"
+		} else if v, ok := fromFile[f.name]; ok {
+			value, f.source = v, "file"
+		} else {
+			continue
+		}
+		if err := fs.Set(f.name, value); err != nil {
+			return fmt.Errorf("invalid value for %s from %s: %w", f.name, f.source, err)
+		}
+	}
+	for name := range fromFile {
+		if !known[name] {
+			return fmt.Errorf("unknown setting %q in %s", name, configFile)
+		}
+	}
+	return nil
+}
+
+// readConfigFile reads a flat JSON object of settings keyed by flag name
+func readConfigFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	raw := map[string]any{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("couldn't parse config file %s: %w", path, err)
+	}
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[k] = v
+		case json.Number:
+			values[k] = v.String()
+		case bool:
+			values[k] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("setting %q in %s must be a string, number or boolean", k, path)
+		}
+	}
+	return values, nil
+}
+
+// validateConfig checks that every required setting was provided
+func validateConfig(fields []*configField) error {
+	var missing []string
+	for _, f := range fields {
+		if f.required && f.source == "default" {
+			missing = append(missing, fmt.Sprintf("%s (env %s)", f.name, f.env))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required settings: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// printConfig writes the loaded settings and their sources, redacting secrets
+func printConfig(w io.Writer, fs *flag.FlagSet, fields []*configField) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+	for _, f := range fields {
+		value := fs.Lookup(f.name).Value.String()
+		if f.secret && value != "" {
+			value = "[REDACTED]"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t(%s)\n", f.name, value, f.source)
+	}
+}
+`
+
+var CONFIG_TEST_BASE = `package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupConfig is a helper function that binds the application
+// settings to a new flag set and parses the given arguments
+func setupConfig(t *testing.T, args ...string) (*config, *flag.FlagSet, []*configField) {
+	t.Helper() // Mark the function as test helper
+	cfg := &config{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fields := cfg.bind(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return cfg, fs, fields
+}
+
+// TestLoadConfig checks that flags take precedence over environment
+// variables, which take precedence over the config file
+func TestLoadConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	content := "{\"port\": 5000, \"shutdown-delay\": \"1s\"}"
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"PORT": "6000", "SHUTDOWN_DELAY": "3s"}
+	getenv := func(k string) string { return env[k] }
+
+	cfg, fs, fields := setupConfig(t, "-shutdown-delay=2s")
+	if err := loadConfig(fs, fields, configFile, getenv); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.port != 6000 {
+		t.Errorf("Expected port from env 6000, got %d.", cfg.port)
+	}
+	if cfg.shutdownDelay != 2*time.Second {
+		t.Errorf("Expected shutdown delay from flag 2s, got %s.", cfg.shutdownDelay)
+	}
+
+	// Without environment variables the config file should be used
+	cfg, fs, fields = setupConfig(t)
+	if err := loadConfig(fs, fields, configFile, func(string) string { return "" }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.port != 5000 {
+		t.Errorf("Expected port from file 5000, got %d.", cfg.port)
+	}
+}
+
+// TestLoadConfigErrors checks that invalid and unknown settings are rejected
+func TestLoadConfigErrors(t *testing.T) {
+	_, fs, fields := setupConfig(t)
+	getenv := func(k string) string {
+		if k == "PORT" {
+			return "not-a-port"
+		}
+		return ""
+	}
+	if err := loadConfig(fs, fields, "", getenv); err == nil {
+		t.Error("Expected error for invalid port from env, got nil.")
+	}
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configFile, []byte("{\"unknown\": 1}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, fs, fields = setupConfig(t)
+	if err := loadConfig(fs, fields, configFile, func(string) string { return "" }); err == nil {
+		t.Error("Expected error for unknown setting in config file, got nil.")
+	}
+}
+
+// TestValidateAndPrintConfig checks that missing required settings are
+// reported and that secrets are redacted when printing configuration
+func TestValidateAndPrintConfig(t *testing.T) {
+	_, fs, fields := setupConfig(t, "-port=5000")
+	if err := loadConfig(fs, fields, "", func(string) string { return "" }); err != nil {
+		t.Fatal(err)
+	}
+	fields[0].secret, fields[1].required = true, true
+	if err := validateConfig(fields); err == nil || !strings.Contains(err.Error(), "shutdown-delay") {
+		t.Errorf("Expected missing shutdown-delay error, got %v.", err)
+	}
+	var out strings.Builder
+	printConfig(&out, fs, fields)
+	if strings.Contains(out.String(), "5000") || !strings.Contains(out.String(), "[REDACTED]") {
+		t.Errorf("Expected port to be redacted, got %q.", out.String())
+	}
+}
 `
 
 var HANDLERS_TEST_BASE = `package main
 
 import (
+	"context"
+	"errors"
 	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // setupAPI is a helper function that sets up
@@ -99,6 +732,8 @@ func setupAPI(t *testing.T) (string, func()) {
 	t.Helper() // Mark the function as test helper
 	app := &application{
 		config: config{},
+		logger: log.New(io.Discard, "", 0),
+		health: newHealthRegistry(),
 	}
 	ts := httptest.NewServer(app.routes())
 	return ts.URL, func() {
@@ -109,9 +744,21 @@ func setupAPI(t *testing.T) (string, func()) {
 // getHelper wraps the Get function in additional logic to
 // assist with testing ease and clarity
 func getHelper(t *testing.T, getUrl string, expBody string, expCode int) (r *http.Response) {
-	r, err := http.Get(getUrl)
+	t.Helper() // Mark the function as test helper
+	return requestHelper(t, http.MethodGet, getUrl, expBody, expCode)
+}
+
+// requestHelper sends a request with the given method and checks
+// that the response has the expected status and content
+func requestHelper(t *testing.T, method string, reqUrl string, expBody string, expCode int) (r *http.Response) {
+	t.Helper() // Mark the function as test helper
+	req, err := http.NewRequest(method, reqUrl, nil)
 	if err != nil {
-		t.Fatalf("error while sending GET request: %q", err)
+		t.Fatal(err)
+	}
+	r, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error while sending %s request: %q", method, err)
 	}
 	// Check if the return code is what we expected
 	if r.StatusCode != expCode {
@@ -141,21 +788,245 @@ func TestIntegration(t *testing.T) {
 	url, cleanup := setupAPI(t)
 	// Close server when testing is complete
 	defer cleanup()
-	// Check that the server is healthy
-	_ = getHelper(t, url+"/v1/healthcheck", "OK", http.StatusOK)
+	// Check that the server is healthy and reports its build information
+	_ = getHelper(t, url+"/v1/healthcheck", "available", http.StatusOK)
+	_ = getHelper(t, url+"/v1/healthcheck", "build_time", http.StatusOK)
+	// Check that the server is ready to receive traffic
+	_ = getHelper(t, url+"/v1/readycheck", "ready", http.StatusOK)
+{{- range .Endpoints}}
+{{- with .GenerateTestRequest}}
+	{{.}}
+{{- end}}
+{{- end}}
+	// Check that unknown paths and methods are rejected
+	_ = getHelper(t, url+"/v1/unknown", "", http.StatusNotFound)
+	_ = requestHelper(t, http.MethodDelete, url+"/v1/healthcheck", "", http.StatusMethodNotAllowed)
+}
+
+// TestRecoverPanic checks that panicking handlers reply with a 500 status
+func TestRecoverPanic(t *testing.T) {
+	app := &application{logger: log.New(io.Discard, "", 0)}
+	handler := app.recoverPanic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("unexpected failure")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected %q, got %q.", http.StatusText(http.StatusInternalServerError), http.StatusText(w.Code))
+	}
+}
+
+// TestReadiness checks that failing dependency checks and graceful
+// shutdown are reported by the readiness endpoint only
+func TestReadiness(t *testing.T) {
+	app := &application{
+		config: config{},
+		logger: log.New(io.Discard, "", 0),
+		health: newHealthRegistry(),
+	}
+	ts := httptest.NewServer(app.routes())
+	defer ts.Close()
+
+	// A failing dependency should fail readiness but not liveness
+	app.health.Register("failing", time.Second, func(ctx context.Context) error {
+		return errors.New("dependency unreachable")
+	})
+	_ = getHelper(t, ts.URL+"/v1/readycheck", "dependency unreachable", http.StatusServiceUnavailable)
+	_ = getHelper(t, ts.URL+"/v1/healthcheck", "available", http.StatusOK)
+
+	// A dependency that hangs should be cut off by its timeout
+	app.health.Register("hanging", 10*time.Millisecond, func(ctx context.Context) error {
+		select {}
+	})
+	_ = getHelper(t, ts.URL+"/v1/readycheck", "deadline exceeded", http.StatusServiceUnavailable)
+
+	// Readiness should fail as soon as shutdown begins
+	app.shuttingDown.Store(true)
+	_ = getHelper(t, ts.URL+"/v1/readycheck", "shutting down", http.StatusServiceUnavailable)
 }
 `
 
-// Creates main.go, handlers.go and handlers_test.go
-func GenerateGoSourceFiles(target string) error {
+// TemplateData contains the values used to render
+// templated files for the generated server
+type TemplateData struct {
+	AppName          string
+	ModName          string
+	Router           RouterBackend        // Router requests are routed with
+	Endpoints        []EndpointDefinition // Built-in endpoints followed by custom endpoints
+	GoVersion        string               // Go version from the go directive of the generated go.mod
+	Port             int
+	ContainerProfile string // Runtime image the container is built from
+	AlpineImage      string // Pinned alpine image used by the container
+	HealthChecks     []HealthCheckDefinition
+	ConfigFields     []ConfigFieldDefinition // Built-in settings followed by custom settings
+	Environment      map[string]string       // Environment passed to the container by docker-compose
+	Services         []ServiceDefinition     // Services run alongside the server by docker-compose
+	Deploy           string                  // Target the deployment files in remote are generated for
+	Kubernetes       KubernetesDefinition
+	Systemd          SystemdDefinition
+	CI               []string // Providers CI pipelines are generated for
+	Vendor           bool     // Whether dependencies are copied into vendor/
+	Platform         string   // Path of the platform module the server imports, if any
+	PlatformVersion  string   // Version of an existing platform module
+	PlatformDir      string   // Directory of a generated platform module, relative to the server
+	ServerDir        string   // Directory of the server in the monorepo it is generated into, (i.e. billing/)
+}
+
+// Collects the values used to render templated files from the
+// given configuration, regardless of whether it was configured
+// through YAML or flags
+func NewTemplateData(conf *YamlConfig) TemplateData {
+	port := conf.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	container := conf.Container
+	if container.Profile == "" {
+		container.Profile = "scratch"
+	}
+	fields := append(builtinConfigFields(port), conf.ConfigFields...)
+	// Pass every setting through to the container, allowing values
+	// set explicitly in the YAML to take precedence
+	env := map[string]string{}
+	for _, f := range fields {
+		env[f.envName()] = f.composeValue()
+	}
+	// The port is fixed as it must match the published port mapping
+	env["PORT"] = strconv.Itoa(port)
+	for k, v := range container.Environment {
+		env[k] = v
+	}
+	router := conf.Router
+	if router == "" {
+		router = "httprouter"
+	}
+	endpoints := append(append([]EndpointDefinition{}, builtinEndpoints...), conf.Endpoints...)
+	platform := conf.Platform.withDefaults(conf.ModName)
+	data := TemplateData{
+		AppName:          conf.AppName,
+		ModName:          conf.ModName,
+		Router:           routerBackends[router],
+		Endpoints:        endpoints,
+		Port:             port,
+		ContainerProfile: container.Profile,
+		AlpineImage:      ALPINE_IMAGE,
+		HealthChecks:     conf.HealthChecks,
+		ConfigFields:     fields,
+		Environment:      env,
+		Services:         container.Services,
+		Deploy:           conf.Deploy,
+		Kubernetes:       conf.Kubernetes.withDefaults(),
+		Systemd:          conf.Systemd.withDefaults(conf.AppName),
+		CI:               conf.CI,
+		Vendor:           conf.Modules.Vendor,
+		Platform:         platform.Module,
+	}
+	if platform.Generate {
+		data.PlatformDir = "./internal/platform"
+	} else {
+		data.PlatformVersion = platform.Version
+	}
+	return data
+}
+
+// Places the server in the directory named after it in a monorepo, whose
+// images are built from the monorepo directory so that the platform
+// module generated alongside the servers can be copied into them
+func (d *TemplateData) inMonorepo() {
+	d.ServerDir = d.AppName + "/"
+	if d.PlatformDir != "" {
+		d.PlatformDir = "../internal/platform"
+	}
+}
+
+// Returns the directory the image of the server is built from,
+// relative to the server
+func (d TemplateData) BuildContext() string {
+	if d.ServerDir != "" {
+		return ".."
+	}
+	return "."
+}
+
+// Returns whether the platform module is generated inside the server
+// rather than alongside the servers of a monorepo
+func (d TemplateData) NestedPlatform() bool {
+	return d.PlatformDir != "" && d.ServerDir == ""
+}
+
+// Returns the directory of the generated platform module in the build
+// context of the image, (i.e. internal/platform)
+func (d TemplateData) PlatformContextDir() string {
+	return path.Join(d.ServerDir, d.PlatformDir)
+}
+
+// Returns the directory the generated platform module is copied to in the
+// image, where the replace directive of go.mod in /src finds it
+func (d TemplateData) PlatformImageDir() string {
+	return path.Join("/src", d.PlatformDir)
+}
+
+// Returns the environment variable of the database the migrations are applied
+// to, which is the first setting named like a database connection string
+func (d TemplateData) DatabaseEnv() string {
+	for _, f := range d.ConfigFields {
+		name := strings.ToLower(f.Name)
+		if f.Type == "string" && (strings.Contains(name, "dsn") || strings.Contains(name, "database")) {
+			return f.envName()
+		}
+	}
+	return "DATABASE_URL"
+}
+
+// Returns the settings which aren't secret
+func (d TemplateData) PlainConfigFields() []ConfigFieldDefinition {
+	var fields []ConfigFieldDefinition
+	for _, f := range d.ConfigFields {
+		if !f.Secret {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Returns the settings which are secret
+func (d TemplateData) SecretConfigFields() []ConfigFieldDefinition {
+	var fields []ConfigFieldDefinition
+	for _, f := range d.ConfigFields {
+		if f.Secret {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Creates main.go, config.go, handlers.go, middleware.go, health.go and their tests
+func GenerateGoSourceFiles(target string, data TemplateData) error {
 	// Create main.go
 	mainFile, err := createFile("cmd/api/main.go", target)
 	if err != nil {
 		return err
 	}
 	// Write main.go content to newly created file
-	// ToDo: Add templating here where necessary
-	if err := writeFile(mainFile, MAIN_BASE); err != nil {
+	if err := writeTemplate(mainFile, MAIN_BASE, data); err != nil {
+		return err
+	}
+	// Create config.go
+	configFile, err := createFile("cmd/api/config.go", target)
+	if err != nil {
+		return err
+	}
+	// Write config.go content to newly created file
+	if err := writeTemplate(configFile, CONFIG_BASE, data); err != nil {
+		return err
+	}
+	// Create config_test.go
+	configTestFile, err := createFile("cmd/api/config_test.go", target)
+	if err != nil {
+		return err
+	}
+	// Write config_test.go content to newly created file
+	if err := writeTemplate(configTestFile, CONFIG_TEST_BASE, data); err != nil {
 		return err
 	}
 	// Create handlers.go
@@ -164,8 +1035,25 @@ func GenerateGoSourceFiles(target string) error {
 		return err
 	}
 	// Write handlers.go content to newly created file
-	// ToDo: Add templating here where necessary
-	if err := writeFile(handlersFile, HANDERS_BASE); err != nil {
+	if err := writeTemplate(handlersFile, HANDERS_BASE, data); err != nil {
+		return err
+	}
+	// Create middleware.go
+	middlewareFile, err := createFile("cmd/api/middleware.go", target)
+	if err != nil {
+		return err
+	}
+	// Write middleware.go content to newly created file
+	if err := writeTemplate(middlewareFile, MIDDLEWARE_BASE, data); err != nil {
+		return err
+	}
+	// Create health.go
+	healthFile, err := createFile("cmd/api/health.go", target)
+	if err != nil {
+		return err
+	}
+	// Write health.go content to newly created file
+	if err := writeTemplate(healthFile, HEALTH_BASE, data); err != nil {
 		return err
 	}
 	// Create handlers_test.go
@@ -174,8 +1062,7 @@ func GenerateGoSourceFiles(target string) error {
 		return err
 	}
 	// Write handlers_test.go content to newly created file
-	// ToDo: Add templating here where necessary
-	if err := writeFile(handlersTestFile, HANDLERS_TEST_BASE); err != nil {
+	if err := writeTemplate(handlersTestFile, HANDLERS_TEST_BASE, data); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationReport contains the outcome of validating a configuration
+// file, with the configuration as make would use it
+type ValidationReport struct {
+	File     string           `json:"file"`
+	Valid    bool             `json:"valid"`
+	Config   *YamlConfig      `json:"config,omitempty"` // Configuration with defaults set, if valid
+	Errors   ValidationErrors `json:"errors"`
+	Warnings ValidationErrors `json:"warnings"`
+}
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:          "validate",
+	Aliases:      []string{"v"},
+	Short:        "Validates a configuration file without generating a server",
+	Long:         "Validates a configuration file without generating a server, printing the configuration with defaults set and any problems found",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		confFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if confFile == "" {
+			return fmt.Errorf("no config argument was provided")
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		report, err := validateConfigFile(confFile, cmd.Flags())
+		if err != nil {
+			return err
+		}
+		if err := writeValidationReport(os.Stdout, report, output); err != nil {
+			return err
+		}
+		if !report.Valid {
+			return fmt.Errorf("%s is invalid, found %d problems", report.File, len(report.Errors))
+		}
+		return nil
+	},
+}
+
+// Validates the configuration file as make would, returning a
+// report of the problems found. An error is only returned if the
+// file can't be read or parsed
+func validateConfigFile(filename string, flags *pflag.FlagSet) (ValidationReport, error) {
+	yamlConf, v, err := readConfig(filename, flags)
+	if err != nil {
+		return ValidationReport{}, err
+	}
+	report := ValidationReport{
+		File:     configFileName(filename),
+		Valid:    len(v.errs) == 0,
+		Errors:   append(ValidationErrors{}, v.errs...),
+		Warnings: append(ValidationErrors{}, v.warnings...),
+	}
+	if report.Valid {
+		report.Config = yamlConf
+	}
+	return report, nil
+}
+
+// Writes the report in the given format, either text or json
+func writeValidationReport(out io.Writer, report ValidationReport, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "text":
+	default:
+		return fmt.Errorf("unsupported output format %q, must be text or json", format)
+	}
+	for _, e := range report.Errors {
+		fmt.Fprintf(out, "Error: %s\n", e.Error())
+	}
+	for _, w := range report.Warnings {
+		fmt.Fprintf(out, "Warning: %s\n", w.Error())
+	}
+	if !report.Valid {
+		return nil
+	}
+	fmt.Fprintf(out, "%s is valid, resolved configuration:\n\n", report.File)
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(report.Config); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringP("config", "c", "", "Configuration file (YAML, JSON or TOML), or - to read it from stdin")
+	validateCmd.Flags().String("config-format", "", "Format of the configuration file (yaml, json or toml, default detected from its extension)")
+	validateCmd.Flags().StringP("output", "o", "text", "Format of the report (text or json)")
+}
@@ -0,0 +1,425 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError describes a problem with a single configuration value
+type ValidationError struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"` // Position of the value in the file, zero if unknown
+	Column  int    `json:"column,omitempty"`
+	Field   string `json:"field"` // Path of the value, (i.e. endpoints[1].method)
+	Message string `json:"message"`
+}
+
+// Returns the problem prefixed by its position, (i.e. talbot.yaml:4:5: port: ...)
+func (e ValidationError) Error() string {
+	prefix := e.File
+	if e.Line > 0 {
+		prefix = fmt.Sprintf("%s:%d:%d", prefix, e.Line, e.Column)
+	}
+	if prefix != "" {
+		prefix += ": "
+	}
+	return fmt.Sprintf("%s%s: %s", prefix, e.Field, e.Message)
+}
+
+// ValidationErrors lists every problem found in a configuration
+type ValidationErrors []ValidationError
+
+// Returns every problem on its own line
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = "  " + err.Error()
+	}
+	return fmt.Sprintf("found %d problems in configuration:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// fieldError is a problem with a single field of a definition
+type fieldError struct {
+	field string
+	err   error
+}
+
+// Returns the problem without the field it applies to
+func (e fieldError) Error() string {
+	return e.err.Error()
+}
+
+// Returns the underlying problem
+func (e fieldError) Unwrap() error {
+	return e.err
+}
+
+// problems lists every problem found with the fields of a definition
+type problems []fieldError
+
+// Records a problem with the given field of the definition
+func (p *problems) addf(field, format string, args ...any) {
+	*p = append(*p, fieldError{field: field, err: fmt.Errorf(format, args...)})
+}
+
+// Returns every problem separated by semicolons
+func (p problems) Error() string {
+	msgs := make([]string, len(p))
+	for i, e := range p {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Returns the problems as an error, or nil if there are none
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return p
+}
+
+// position is the file, line and column of a value in a YAML document
+type position struct {
+	file         string // File the value was read from, if not the configuration file itself
+	line, column int
+}
+
+// validator collects the problems found in a configuration, locating
+// them in the YAML document the configuration was decoded from
+type validator struct {
+	positions map[string]position   // Positions of values by path
+	files     map[*yaml.Node]string // Files nodes were read from, for documents merged from several files
+	errs      ValidationErrors
+	warnings  ValidationErrors    // Settings which are valid but likely mistaken
+	deferred  []fieldError        // Values not allowed by the schema, reported last
+	overrides map[string]string   // Sources of values set outside the file by path, (i.e. --port)
+	resolved  map[*yaml.Node]bool // Values already interpolated, which aliases refer to again
+}
+
+// Returns a validator locating problems in the given YAML document,
+// whose nodes were read from the given files
+func newValidator(doc *yaml.Node, files map[*yaml.Node]string) *validator {
+	v := &validator{positions: map[string]position{}, files: files, overrides: map[string]string{}, resolved: map[*yaml.Node]bool{}}
+	if doc != nil {
+		v.index("", doc)
+	}
+	return v
+}
+
+// Records the positions of the node and its children by path. Values of
+// mappings are located at their key, which is where editors jump to
+func (v *validator) index(path string, n *yaml.Node) {
+	if _, ok := v.positions[path]; !ok {
+		v.positions[path] = position{v.files[n], n.Line, n.Column}
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			v.index(path, c)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := joinPath(path, n.Content[i].Value)
+			v.positions[key] = position{v.files[n.Content[i]], n.Content[i].Line, n.Content[i].Column}
+			v.index(key, n.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			v.index(fmt.Sprintf("%s[%d]", path, i), c)
+		}
+	case yaml.AliasNode:
+		v.index(path, n.Alias)
+	}
+}
+
+// Records err, if any, against the value at path, recording each
+// problem separately if err lists problems with individual fields
+func (v *validator) check(path string, err error) {
+	if err == nil {
+		return
+	}
+	var p problems
+	if errors.As(err, &p) {
+		for _, e := range p {
+			v.report(joinPath(path, e.field), e.err.Error())
+		}
+		return
+	}
+	var fe fieldError
+	if errors.As(err, &fe) {
+		path, err = joinPath(path, fe.field), fe.err
+	}
+	v.report(path, err.Error())
+}
+
+// Records a problem with the value at path, unless a problem has already
+// been recorded for the value or one containing it
+func (v *validator) report(path, message string) {
+	for _, e := range v.errs {
+		if path == e.Field || strings.HasPrefix(path, e.Field+".") || strings.HasPrefix(path, e.Field+"[") {
+			return
+		}
+	}
+	// Values missing from the document are located at the closest parent
+	pos, ok := v.positions[path]
+	for parent := path; !ok && parent != ""; {
+		parent = parentPath(parent)
+		pos, ok = v.positions[parent]
+	}
+	v.errs = append(v.errs, ValidationError{File: pos.file, Line: pos.line, Column: pos.column, Field: path, Message: message})
+}
+
+// Records a warning about the value at path, which doesn't stop the
+// configuration from being used
+func (v *validator) warn(path, message string) {
+	pos := v.positions[path]
+	v.warnings = append(v.warnings, ValidationError{File: pos.file, Line: pos.line, Column: pos.column, Field: path, Message: message})
+}
+
+// Records a value which isn't allowed by the schema, once the values
+// have been checked by talbot, whose problems are more descriptive
+func (v *validator) reportLater(path, message string) {
+	v.deferred = append(v.deferred, fieldError{path, errors.New(message)})
+}
+
+// Records the values which aren't allowed by the schema and haven't
+// already been reported
+func (v *validator) reportDeferred() {
+	for _, e := range v.deferred {
+		v.report(e.field, e.err.Error())
+	}
+	v.deferred = nil
+}
+
+// Forgets the problems found with the value at path and the values
+// it contains, including those not yet reported
+func (v *validator) discard(path string) {
+	within := func(field string) bool {
+		for p := field; p != ""; p = parentPath(p) {
+			if p == path {
+				return true
+			}
+		}
+		return false
+	}
+	errs := v.errs[:0]
+	for _, e := range v.errs {
+		if !within(e.Field) {
+			errs = append(errs, e)
+		}
+	}
+	v.errs = errs
+	deferred := v.deferred[:0]
+	for _, e := range v.deferred {
+		if !within(e.field) {
+			deferred = append(deferred, e)
+		}
+	}
+	v.deferred = deferred
+}
+
+// Returns whether the value at path is set in the document
+func (v *validator) has(path string) bool {
+	_, ok := v.positions[path]
+	return ok
+}
+
+// Attributes the problems and warnings found to the given file, or
+// to the file, flag or environment variable their value was set
+// through, in the order they appear in each file
+func (v *validator) attribute(file string) {
+	for _, errs := range []ValidationErrors{v.errs, v.warnings} {
+		for i := range errs {
+			if errs[i].File == "" {
+				errs[i].File = file
+			}
+			for path := errs[i].Field; path != ""; path = parentPath(path) {
+				if source, ok := v.overrides[path]; ok {
+					errs[i].File, errs[i].Line, errs[i].Column = source, 0, 0
+					break
+				}
+			}
+		}
+		sort.SliceStable(errs, func(i, j int) bool {
+			a, b := errs[i], errs[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+		})
+	}
+}
+
+// Returns the problems found, attributed to the given file
+func (v *validator) result(file string) error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	v.attribute(file)
+	return v.errs
+}
+
+// Descriptions of JSON types used in problems
+var schemaTypeDescriptions = map[string]string{
+	"object":  "a mapping",
+	"array":   "a list",
+	"string":  "a string",
+	"integer": "an integer",
+	"number":  "a number",
+	"boolean": "true or false",
+}
+
+// Checks a YAML node against the schema of the value it is decoded into,
+// recording unknown fields, values of the wrong type and values which
+// aren't allowed. Values of the wrong type are replaced with null so that
+// the rest of the document can be decoded, while values which aren't
+// allowed are reported once reportDeferred is called
+func (v *validator) checkNode(path string, n *yaml.Node, s *jsonSchema) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			v.checkNode(path, c, s)
+		}
+		return
+	case yaml.AliasNode:
+		v.checkNode(path, n.Alias, s)
+		return
+	}
+	// Values referred to by aliases are only interpolated once, as
+	// interpolating their result again would resolve escaped placeholders
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!str" && strings.Contains(n.Value, "$") && !v.resolved[n] {
+		v.resolved[n] = true
+		v.interpolate(path, n, s)
+	}
+	typ := nodeType(n)
+	if typ == "null" {
+		return
+	}
+	if !s.Type.allows(typ) {
+		v.report(path, typeMismatch(n, typ, s.Type))
+		*n = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: n.Line, Column: n.Column}
+		return
+	}
+	switch typ {
+	case "object":
+		set := map[string]bool{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			name := n.Content[i].Value
+			set[name] = true
+			key := joinPath(path, name)
+			if field, ok := s.Properties[name]; ok {
+				v.checkNode(key, n.Content[i+1], field)
+			} else if values, ok := s.AdditionalProperties.(*jsonSchema); ok {
+				v.checkNode(key, n.Content[i+1], values)
+			} else {
+				v.report(key, "unknown field")
+			}
+		}
+		for _, name := range s.Required {
+			if !set[name] {
+				v.reportLater(joinPath(path, name), fmt.Sprintf("missing required field `%s`", name))
+			}
+		}
+	case "array":
+		for i, c := range n.Content {
+			v.checkNode(fmt.Sprintf("%s[%d]", path, i), c, s.Items)
+		}
+	case "string":
+		v.checkString(path, n.Value, s)
+	case "integer":
+		if i, err := strconv.Atoi(n.Value); err == nil && s.Maximum != 0 && i > s.Maximum {
+			v.reportLater(path, fmt.Sprintf("%d must be at most %d", i, s.Maximum))
+		}
+	}
+}
+
+// Records a problem if the string isn't one of the allowed values
+// or doesn't match the pattern of the schema
+func (v *validator) checkString(path, value string, s *jsonSchema) {
+	if len(s.Enum) > 0 {
+		for _, allowed := range s.Enum {
+			if value == allowed {
+				return
+			}
+		}
+		v.reportLater(path, fmt.Sprintf("unsupported value %q, must be one of %s", value, strings.Join(s.Enum, ", ")))
+		return
+	}
+	if s.MaxLength > 0 && len(value) > s.MaxLength {
+		v.reportLater(path, fmt.Sprintf("%q must be at most %d characters", value, s.MaxLength))
+		return
+	}
+	if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(value) {
+		v.reportLater(path, fmt.Sprintf("%q must match the pattern %s", value, s.Pattern))
+	}
+}
+
+// Returns the JSON type of a YAML node
+func nodeType(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch n.ShortTag() {
+	case "!!null":
+		return "null"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	}
+	return "string"
+}
+
+// Returns a problem describing a value of the wrong type,
+// (i.e. a number where a string is expected, which needs quotes)
+func typeMismatch(n *yaml.Node, typ string, allowed schemaTypes) string {
+	descriptions := make([]string, len(allowed))
+	for i, t := range allowed {
+		descriptions[i] = schemaTypeDescriptions[t]
+	}
+	expected := descriptions[len(descriptions)-1]
+	if len(descriptions) > 1 {
+		expected = strings.Join(descriptions[:len(descriptions)-1], ", ") + " or " + expected
+	}
+	got := strings.TrimPrefix(n.ShortTag(), "!!")
+	if n.Kind != yaml.ScalarNode {
+		got = strings.TrimPrefix(schemaTypeDescriptions[typ], "a ")
+	}
+	message := fmt.Sprintf("expected %s, got %s", expected, got)
+	if allowed.allows("string") && n.Kind == yaml.ScalarNode {
+		message += ", quote the value to use it as a string"
+	}
+	return message
+}
+
+// Returns the path of a field of the value at path
+func joinPath(path, field string) string {
+	if path == "" || strings.HasPrefix(field, "[") {
+		return path + field
+	}
+	return path + "." + field
+}
+
+// Matches the last field or index of a path
+var lastPathElementRegex = regexp.MustCompile(`(\.[^.\[]*|\[\d+\])$`)
+
+// Returns the path of the value containing the value at path
+func parentPath(path string) string {
+	parent := lastPathElementRegex.ReplaceAllString(path, "")
+	if parent == path {
+		return ""
+	}
+	return parent
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// writeConfig is a helper function that writes a configuration file
+// with the given content to a temporary directory
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper() // Mark the function as test helper
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// locations returns the file, position and field of each problem,
+// (i.e. talbot.yaml:2:1 port)
+func locations(errs ValidationErrors) []string {
+	var locs []string
+	for _, e := range errs {
+		locs = append(locs, fmt.Sprintf("%s:%d:%d %s", filepath.Base(e.File), e.Line, e.Column, e.Field))
+	}
+	return locs
+}
+
+// TestValidatePositions checks that problems are located at the
+// values they are found with, in the order they appear in the file
+func TestValidatePositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected []string
+	}{
+		{"valid", "appName: svc\n", nil},
+		{"wrong type", "appName: svc\nport: abc\n", []string{"talbot.yaml:2:1 port"}},
+		{"missing field", "port: 4000\n", []string{"talbot.yaml:1:1 appName"}},
+		{"unknown field", "appName: svc\ncontainer:\n  profile: scratch\n  color: red\n", []string{"talbot.yaml:4:3 container.color"}},
+		{"list item", "appName: svc\nendpoints:\n  - path: /v1/a\n  - path: /v1/b\n    method: fetch\n", []string{"talbot.yaml:5:5 endpoints[1].method"}},
+		{"missing list item field", "appName: svc\nendpoints:\n  - method: get\n", []string{"talbot.yaml:3:5 endpoints[0].path"}},
+		{"keyword", "appName: svc\nconfig:\n  - name: type\n    type: string\n", []string{"talbot.yaml:3:5 config[0].name"}},
+		{"git author without a name", "appName: svc\ngit:\n  author: jane@example.com\n", []string{"talbot.yaml:3:3 git.author"}},
+		{"several problems", "router: nope\nappName: Bad_Name\nport: 70000\n", []string{"talbot.yaml:1:1 router", "talbot.yaml:2:1 appName", "talbot.yaml:3:1 port"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := writeConfig(t, "talbot.yaml", tt.config)
+			_, v, err := readConfig(filename, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := locations(v.errs); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected problems at %v, got %v.", tt.expected, v.errs)
+			}
+		})
+	}
+}
+
+// TestReportDeferred checks that values which aren't allowed by the schema
+// are only reported if no other problem was found with them or a value
+// containing them
+func TestReportDeferred(t *testing.T) {
+	tests := []struct {
+		name     string
+		reported []string // Paths of problems found by talbot
+		deferred []string // Paths of values not allowed by the schema
+		expected []string
+	}{
+		{"deferred only", nil, []string{"router"}, []string{"router"}},
+		{"same value", []string{"router"}, []string{"router"}, []string{"router"}},
+		{"containing value", []string{"endpoints[0]"}, []string{"endpoints[0].path"}, []string{"endpoints[0]"}},
+		{"contained value", []string{"endpoints[0].path"}, []string{"endpoints[0]"}, []string{"endpoints[0].path", "endpoints[0]"}},
+		{"other value", []string{"port"}, []string{"router", "router"}, []string{"port", "router"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newValidator(nil, nil)
+			for _, path := range tt.deferred {
+				v.reportLater(path, "not allowed by the schema")
+			}
+			for _, path := range tt.reported {
+				v.report(path, "found by talbot")
+			}
+			v.reportDeferred()
+			var got []string
+			for _, e := range v.errs {
+				got = append(got, e.Field)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected problems with %v, got %v.", tt.expected, v.errs)
+			}
+			if len(v.deferred) != 0 {
+				t.Errorf("Expected no problems left to report, got %v.", v.deferred)
+			}
+		})
+	}
+
+	// Problems found by talbot describe the value better than the schema
+	filename := writeConfig(t, "talbot.yaml", "appName: svc\nrouter: nope\nendpoints:\n  - method: get\n")
+	_, v, err := readConfig(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`unsupported router "nope", must be one of chi, echo, gin, gorilla, httprouter, stdlib`,
+		`endpoint path "" must start and must not end with /`,
+	}
+	var got []string
+	for _, e := range v.errs {
+		got = append(got, e.Message)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected problems %q, got %q.", expected, got)
+	}
+}
+
+// TestOverrideAttribution checks that values set through the environment
+// and flags replace the problems found with the values in the file, and
+// that problems with the values set are attributed to their source
+func TestOverrideAttribution(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		env      map[string]string
+		flags    map[string]string
+		expected []string
+	}{
+		{"env replaces invalid value", "appName: svc\nport: 70000\n", map[string]string{"TALBOT_PORT": "8080"}, nil, nil},
+		{"flag replaces value of the wrong type", "appName: svc\nport: abc\n", nil, map[string]string{"port": "8080"}, nil},
+		{"flag replaces value not allowed by the schema", "appName: svc\nrouter: nope\n", nil, map[string]string{"router": "chi"}, nil},
+		{"flag replaces list items", "appName: svc\nci: [nope]\n", nil, map[string]string{"ci": "github"}, nil},
+		{"flag replaces env", "appName: svc\n", map[string]string{"TALBOT_ROUTER": "nope"}, map[string]string{"router": "chi"}, nil},
+		{"other values kept", "appName: svc\nport: abc\nrouter: nope\n", nil, map[string]string{"router": "chi"}, []string{"talbot.yaml:2:1 port"}},
+		{"invalid env", "appName: svc\n", map[string]string{"TALBOT_PORT": "70000"}, nil, []string{"TALBOT_PORT:0:0 port"}},
+		{"invalid flag", "appName: svc\nport: 8080\n", nil, map[string]string{"port": "abc"}, []string{"--port:0:0 port"}},
+		{"invalid nested flag", "appName: svc\n", nil, map[string]string{"container-profile": "nope"}, []string{"--container-profile:0:0 container.profile"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, value := range tt.env {
+				t.Setenv(k, value)
+			}
+			flags := pflag.NewFlagSet("make", pflag.ContinueOnError)
+			for _, s := range flagSettings {
+				flags.String(s.flag, "", "")
+			}
+			for name, value := range tt.flags {
+				if err := flags.Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			filename := writeConfig(t, "talbot.yaml", tt.config)
+			_, v, err := readConfig(filename, flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := locations(v.errs); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected problems at %v, got %v.", tt.expected, v.errs)
+			}
+		})
+	}
+}
@@ -2,13 +2,20 @@ module github.com/rohitkochhar/talbot
 
 go 1.19
 
-require k8s.io/apimachinery v0.26.3
+require (
+	github.com/pelletier/go-toml/v2 v2.2.2
+	github.com/spf13/cobra v1.6.1
+	github.com/spf13/pflag v1.0.5
+	golang.org/x/mod v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
+	k8s.io/apimachinery v0.26.3
+	sigs.k8s.io/yaml v1.3.0
+)
 
 require (
+	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/cobra v1.6.1 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
+	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	sigs.k8s.io/json v0.0.0-20220713155537-f223a00ba0e2 // indirect
-	sigs.k8s.io/yaml v1.3.0 // indirect
 )
@@ -0,0 +1,21 @@
+#!/bin/bash
+
+# ----------------------------------------------------------------------------
+# Integration test
+# schema_test.sh
+# - Checks that the published schema is up to date and that the example
+#   configurations are valid, run from the repository root
+# ----------------------------------------------------------------------------
+# Fail on errors
+set -e
+
+# Rebuild binary
+go build
+
+# Check that the published schema matches the one built into talbot
+./talbot schema | diff talbot.schema.json -
+
+# Validate example configurations
+for config in config-examples/*.yaml config-examples/*.json config-examples/*.toml; do
+    ./talbot validate -c "${config}" > /dev/null
+done